@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/identifiers"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/osutil"
+	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func newCloneCommand() *cobra.Command {
+	var cloneCommand = &cobra.Command{
+		Use:               "clone SRC DST",
+		Short:             "Clone an instance of Lima.",
+		Args:              cobra.ExactArgs(2),
+		RunE:              cloneAction,
+		ValidArgsFunction: cloneBashComplete,
+	}
+	cloneCommand.Flags().BoolP("force", "f", false, "clone the instance even if it is running (the disk may be inconsistent)")
+	return cloneCommand
+}
+
+func cloneAction(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	srcName, dstName := args[0], args[1]
+
+	src, err := store.Inspect(srcName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", srcName, srcName)
+		}
+		return err
+	}
+	if src.Status != store.StatusStopped && !force {
+		return fmt.Errorf("expected status %q, got %q (maybe use `limactl clone -f`?)", store.StatusStopped, src.Status)
+	}
+
+	if err := identifiers.Validate(dstName); err != nil {
+		return err
+	}
+	dstDir, err := store.InstanceDir(dstName)
+	if err != nil {
+		return err
+	}
+	// the full path of the socket name must be less than UNIX_PATH_MAX chars.
+	maxSockName := filepath.Join(dstDir, filenames.LongestSock)
+	if len(maxSockName) >= osutil.UnixPathMax {
+		return fmt.Errorf("instance name %q too long: %q must be less than UNIX_PATH_MAX=%d characers, but is %d",
+			dstName, maxSockName, osutil.UnixPathMax, len(maxSockName))
+	}
+	if _, err := os.Stat(dstDir); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("instance %q already exists (%q)", dstName, dstDir)
+	}
+
+	yBytes, err := os.ReadFile(filepath.Join(src.Dir, filenames.LimaYAML))
+	if err != nil {
+		return err
+	}
+	dstYAMLPath := filepath.Join(dstDir, filenames.LimaYAML)
+	yBytes, err = regenerateIdentity(yBytes, dstYAMLPath)
+	if err != nil {
+		return err
+	}
+	y, err := limayaml.Load(yBytes, dstYAMLPath)
+	if err != nil {
+		return err
+	}
+	if err := limayaml.Validate(*y, false); err != nil {
+		return err
+	}
+
+	logrus.Infof("Cloning instance %q to %q", srcName, dstName)
+	if err := os.MkdirAll(dstDir, 0700); err != nil {
+		return err
+	}
+	if err := cloneInstanceDir(src.Dir, dstDir, yBytes); err != nil {
+		if rmErr := os.RemoveAll(dstDir); rmErr != nil {
+			logrus.WithError(rmErr).Warnf("Failed to remove %q", dstDir)
+		}
+		return err
+	}
+	logrus.Infof("Cloned instance %q to %q. Run `limactl start %s` to start the instance.", srcName, dstName, dstName)
+	return nil
+}
+
+func cloneInstanceDir(srcDir, dstDir string, yBytes []byte) error {
+	if err := os.WriteFile(filepath.Join(dstDir, filenames.LimaYAML), yBytes, 0644); err != nil {
+		return err
+	}
+	// cidata.iso is not copied, as it is regenerated with a new cloud-init instance ID on every start
+	return qemu.CloneDisk(qemu.Config{InstanceDir: srcDir}, qemu.Config{InstanceDir: dstDir})
+}
+
+// regenerateIdentity resets `ssh.localPort` and regenerates `macAddress` of the networks,
+// so that the clone can run in parallel with the source instance.
+// The slirp MAC address does not need to be regenerated, as it is derived from the instance directory.
+//
+// The YAML is returned as-is (with comments) when it does not need to be modified.
+func regenerateIdentity(yBytes []byte, filePath string) ([]byte, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(yBytes, &m); err != nil {
+		return nil, err
+	}
+	var modified bool
+	regenerateMACAddresses := func(nws []interface{}) {
+		for i, nw := range nws {
+			nwMap, ok := nw.(yaml.MapSlice)
+			if !ok {
+				continue
+			}
+			for j := range nwMap {
+				if nwMap[j].Key == "macAddress" && nwMap[j].Value != "" {
+					// same as limayaml.FillDefault
+					nwMap[j].Value = limayaml.MACAddress(fmt.Sprintf("%s#%d", filePath, i))
+					modified = true
+				}
+			}
+		}
+	}
+	for i := range m {
+		v, ok := m[i].Value.(yaml.MapSlice)
+		switch m[i].Key {
+		case "ssh":
+			for j := range v {
+				if v[j].Key == "localPort" && v[j].Value != 0 {
+					v[j].Value = 0
+					modified = true
+				}
+			}
+		case "networks":
+			if nws, ok := m[i].Value.([]interface{}); ok {
+				regenerateMACAddresses(nws)
+			}
+		case "network":
+			if !ok {
+				continue
+			}
+			for j := range v {
+				if nws, ok := v[j].Value.([]interface{}); ok && v[j].Key == "vde" {
+					regenerateMACAddresses(nws)
+				}
+			}
+		}
+	}
+	if !modified {
+		return yBytes, nil
+	}
+	logrus.Info("Resetting `ssh.localPort` and regenerating `macAddress` of the networks (comments in the YAML are not preserved)")
+	return yaml.Marshal(m)
+}
+
+func cloneBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return bashCompleteInstanceNames(cmd)
+}
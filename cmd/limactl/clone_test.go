@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestRegenerateIdentity(t *testing.T) {
+	const filePath = "/home/user/.lima/clone/lima.yaml"
+
+	t.Run("unmodified", func(t *testing.T) {
+		// returned as-is, including the comments
+		for _, s := range []string{
+			"# comment\ncpus: 2\n",
+			"ssh:\n  localPort: 0 # comment\n",
+			"networks:\n- lima: shared # comment\n",
+		} {
+			b, err := regenerateIdentity([]byte(s), filePath)
+			assert.NilError(t, err)
+			assert.Equal(t, s, string(b))
+		}
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		s := `
+ssh:
+  localPort: 60022
+  loadDotSSHPubKeys: false
+networks:
+- lima: shared
+  macAddress: "52:55:55:00:00:01"
+- lima: bridged
+network:
+  vde:
+  - vnl: /var/run/vde.ctl
+    macAddress: "52:55:55:00:00:02"
+`
+		b, err := regenerateIdentity([]byte(s), filePath)
+		assert.NilError(t, err)
+		var y limayaml.LimaYAML
+		assert.NilError(t, yaml.Unmarshal(b, &y))
+		assert.Equal(t, 0, y.SSH.LocalPort)
+		assert.Equal(t, false, *y.SSH.LoadDotSSHPubKeys)
+		assert.Equal(t, 2, len(y.Networks))
+		assert.Equal(t, limayaml.MACAddress(filePath+"#0"), y.Networks[0].MACAddress)
+		assert.Equal(t, "", y.Networks[1].MACAddress)
+		assert.Equal(t, 1, len(y.Network.VDEDeprecated))
+		assert.Equal(t, limayaml.MACAddress(filePath+"#0"), y.Network.VDEDeprecated[0].MACAddress)
+	})
+}
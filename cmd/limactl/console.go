@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// consoleDetachKey is Ctrl-], as in telnet(1) and virsh(1)
+const consoleDetachKey = 0x1d
+
+func newConsoleCommand() *cobra.Command {
+	var consoleCommand = &cobra.Command{
+		Use:   "console INSTANCE",
+		Short: "Attach to the serial console of an instance",
+		Long: `Attach to the serial console of an instance.
+
+The console is useful for recovering an instance whose SSH or networking is broken.
+Press Ctrl-] to detach from the console. The instance keeps running after detaching.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              consoleAction,
+		ValidArgsFunction: consoleBashComplete,
+	}
+	return consoleCommand
+}
+
+func consoleAction(cmd *cobra.Command, args []string) error {
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	// The hostagent is not needed, so that the console works even when the instance is "Broken"
+	if inst.QemuPID == 0 {
+		return fmt.Errorf("instance %q is not running (QEMU is not running), run `limactl start %s` to start the instance", instName, instName)
+	}
+
+	serialSock := filepath.Join(inst.Dir, filenames.SerialSock)
+	conn, err := net.Dial("unix", serialSock)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the serial console %q: %w", serialSock, err)
+	}
+	defer conn.Close()
+
+	logrus.Infof("Connected to the serial console of %q. Press Enter to show the prompt, and press Ctrl-] to detach.", instName)
+	stdinFd := int(os.Stdin.Fd())
+	if term.IsTerminal(stdinFd) {
+		oldState, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = term.Restore(stdinFd, oldState)
+			fmt.Fprintln(cmd.ErrOrStderr())
+			logrus.Infof("Detached from the serial console of %q", instName)
+		}()
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(cmd.OutOrStdout(), conn)
+		errCh <- err
+	}()
+	go func() {
+		errCh <- copyUntilDetachKey(conn, os.Stdin)
+	}()
+	return <-errCh
+}
+
+// copyUntilDetachKey copies src to dst until src reaches EOF or the detach key is read.
+func copyUntilDetachKey(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			b := buf[:n]
+			i := bytes.IndexByte(b, consoleDetachKey)
+			if i >= 0 {
+				b = b[:i]
+			}
+			if _, werr := dst.Write(b); werr != nil {
+				return werr
+			}
+			if i >= 0 {
+				return nil
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func consoleBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
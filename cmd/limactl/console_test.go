@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCopyUntilDetachKey(t *testing.T) {
+	type testCase struct {
+		name     string
+		src      []byte
+		expected string
+	}
+	testCases := []testCase{
+		{name: "detach key mid-buffer", src: []byte("echo hello\n\x1dexit\n"), expected: "echo hello\n"},
+		{name: "detach key as the first byte", src: []byte("\x1dexit\n"), expected: ""},
+		{name: "EOF", src: []byte("echo hello\n"), expected: "echo hello\n"},
+		{name: "empty", src: nil, expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dst bytes.Buffer
+			src := bytes.NewReader(tc.src)
+			assert.NilError(t, copyUntilDetachKey(&dst, src))
+			assert.Equal(t, tc.expected, dst.String())
+		})
+	}
+
+	// the detach key in a later read stops copying as well
+	src := bytes.NewReader(append(bytes.Repeat([]byte("a"), 1024), consoleDetachKey, 'b'))
+	var dst bytes.Buffer
+	assert.NilError(t, copyUntilDetachKey(&dst, src))
+	assert.Equal(t, 1024, dst.Len())
+}
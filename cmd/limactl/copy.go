@@ -80,6 +80,9 @@ func copyAction(cmd *cobra.Command, args []string) error {
 			if inst.Status == store.StatusStopped {
 				return fmt.Errorf("instance %q is stopped, run `limactl start %s` to start the instance", instName, instName)
 			}
+			if inst.Status == store.StatusSuspended {
+				return fmt.Errorf("instance %q is suspended, run `limactl resume %s` to resume the instance", instName, instName)
+			}
 			scpArgs = append(scpArgs, fmt.Sprintf("scp://%s@127.0.0.1:%d/%s", u.Username, inst.SSHLocalPort, path[1]))
 			instDirs[instName] = inst.Dir
 		default:
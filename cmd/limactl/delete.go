@@ -52,6 +52,12 @@ func deleteInstance(inst *store.Instance, force bool) error {
 	}
 
 	stopInstanceForcibly(inst)
+	if err := discardSavedState(inst); err != nil {
+		logrus.WithError(err).Warnf("failed to discard the saved state of instance %q", inst.Name)
+	}
+	if err := unlockDisks(inst); err != nil {
+		logrus.WithError(err).Warnf("failed to unlock the additional disks of instance %q", inst.Name)
+	}
 
 	if err := os.RemoveAll(inst.Dir); err != nil {
 		return fmt.Errorf("failed to remove %q: %w", inst.Dir, err)
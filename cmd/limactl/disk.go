@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/go-units"
+	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+func newDiskCommand() *cobra.Command {
+	var diskCommand = &cobra.Command{
+		Use:   "disk",
+		Short: "Manage instance disks and additional disks",
+	}
+	diskCommand.AddCommand(
+		newDiskResizeCommand(),
+		newDiskCreateCommand(),
+		newDiskListCommand(),
+		newDiskDeleteCommand(),
+	)
+	return diskCommand
+}
+
+func newDiskResizeCommand() *cobra.Command {
+	var diskResizeCommand = &cobra.Command{
+		Use:     "resize INSTANCE SIZE",
+		Short:   "Resize the disk of a stopped instance",
+		Example: "  limactl disk resize default 200GiB",
+		Args:    cobra.ExactArgs(2),
+		RunE:    diskResizeAction,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return bashCompleteInstanceNames(cmd)
+		},
+	}
+	return diskResizeCommand
+}
+
+func diskResizeAction(cmd *cobra.Command, args []string) error {
+	instName, sizeStr := args[0], args[1]
+	size, err := units.RAMInBytes(sizeStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse size %q: %w", sizeStr, err)
+	}
+
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusStopped {
+		return fmt.Errorf("expected status %q, got %q (run `limactl stop %s` first)", store.StatusStopped, inst.Status, instName)
+	}
+	if _, err := os.Stat(filepath.Join(inst.Dir, filenames.DiffDisk)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not have %q yet, edit `disk` in %q instead",
+				instName, filenames.DiffDisk, filepath.Join(inst.Dir, filenames.LimaYAML))
+		}
+		return err
+	}
+
+	qCfg := qemu.Config{
+		Name:        inst.Name,
+		InstanceDir: inst.Dir,
+	}
+	if err := qemu.ResizeDisk(qCfg, size); err != nil {
+		return err
+	}
+	// Update `disk` in lima.yaml too, so that `limactl start` does not attempt to resize the disk again
+	yamlPath := filepath.Join(inst.Dir, filenames.LimaYAML)
+	yBytes, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return err
+	}
+	yBytes, err = setYAMLDisk(yBytes, sizeStr)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(yamlPath, yBytes, 0644); err != nil {
+		return err
+	}
+	logrus.Infof("The root filesystem will be grown on the next boot of instance %q", instName)
+	return nil
+}
+
+// setYAMLDisk sets the top-level `disk` field.
+// The line of the field is edited in place to preserve the comments in the YAML.
+// When the field cannot be edited in place, the YAML is marshaled again without the comments.
+func setYAMLDisk(yBytes []byte, size string) ([]byte, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(yBytes, &m); err != nil {
+		return nil, err
+	}
+	expected, err := setMapSliceField(append(yaml.MapSlice{}, m...), []string{"disk"}, size)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := setYAMLDiskLine(yBytes, size); ok {
+		// The result is verified, as the line-based editing is not aware of the YAML syntax
+		var actual yaml.MapSlice
+		if err := yaml.Unmarshal(b, &actual); err == nil && reflect.DeepEqual(expected, actual) {
+			return b, nil
+		}
+	}
+	logrus.Warn("Failed to edit the `disk` field in place, setting the field without preserving the comments in the YAML")
+	return yaml.Marshal(expected)
+}
+
+// setYAMLDiskLine replaces the value of the top-level `disk` line, keeping the comment on the line.
+// The line is appended when the YAML does not have the line.
+func setYAMLDiskLine(yBytes []byte, size string) ([]byte, bool) {
+	value := strconv.Quote(size)
+	lines := strings.SplitAfter(string(yBytes), "\n")
+	for i, line := range lines {
+		var rest string
+		for _, key := range []string{"disk:", "\"disk\":", "'disk':"} {
+			if strings.HasPrefix(line, key) {
+				rest = strings.TrimPrefix(line, key)
+				break
+			}
+		}
+		if rest == "" || (rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\n') {
+			continue
+		}
+		eol := rest[len(strings.TrimRight(rest, "\r\n")):]
+		rest = strings.TrimSpace(rest)
+		var comment string
+		switch {
+		case strings.HasPrefix(rest, "#"):
+			comment = rest
+		case strings.HasPrefix(rest, "\""), strings.HasPrefix(rest, "'"):
+			if j := strings.Index(rest[1:], rest[:1]); j >= 0 {
+				comment = strings.TrimSpace(rest[j+2:])
+			}
+		default:
+			if j := strings.Index(rest, " #"); j >= 0 {
+				comment = rest[j+1:]
+			}
+		}
+		lines[i] = "disk: " + value
+		if comment != "" {
+			lines[i] += " " + comment
+		}
+		lines[i] += eol
+		return []byte(strings.Join(lines, "")), true
+	}
+	s := string(yBytes)
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return []byte(s + "disk: " + value + "\n"), true
+}
+
+func newDiskCreateCommand() *cobra.Command {
+	var diskCreateCommand = &cobra.Command{
+		Use:   "create DISK",
+		Short: "Create an additional disk",
+		Long: `Create an additional disk, which can be attached to instances with "additionalDisks" in lima.yaml.
+The disk is formatted on the first boot of an instance, and survives "limactl delete" of the instance.`,
+		Example: "  limactl disk create data --size 10GiB",
+		Args:    cobra.ExactArgs(1),
+		RunE:    diskCreateAction,
+	}
+	diskCreateCommand.Flags().String("size", "", "size of the disk, e.g., 10GiB (required)")
+	_ = diskCreateCommand.MarkFlagRequired("size")
+	return diskCreateCommand
+}
+
+func diskCreateAction(cmd *cobra.Command, args []string) error {
+	sizeStr, err := cmd.Flags().GetString("size")
+	if err != nil {
+		return err
+	}
+	size, err := units.RAMInBytes(sizeStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse size %q: %w", sizeStr, err)
+	}
+	if size <= 0 {
+		return fmt.Errorf("size must be positive, got %q", sizeStr)
+	}
+
+	diskName := args[0]
+	diskDir, err := store.DiskDir(diskName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(diskDir); !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("disk %q already exists (%q)", diskName, diskDir)
+	}
+	if err := os.MkdirAll(diskDir, 0700); err != nil {
+		return err
+	}
+	if err := qemu.CreateDataDisk(diskDir, size); err != nil {
+		_ = os.RemoveAll(diskDir)
+		return err
+	}
+	logrus.Infof("Created disk %q (%s). Add it to `additionalDisks` in lima.yaml to attach it to an instance.",
+		diskName, units.BytesSize(float64(size)))
+	return nil
+}
+
+func newDiskListCommand() *cobra.Command {
+	var diskListCommand = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the additional disks",
+		Args:    cobra.NoArgs,
+		RunE:    diskListAction,
+	}
+	diskListCommand.Flags().Bool("json", false, "JSONify output")
+	return diskListCommand
+}
+
+func diskListAction(cmd *cobra.Command, args []string) error {
+	jsonFormat, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+	diskNames, err := store.Disks()
+	if err != nil {
+		return err
+	}
+	var disks []*store.Disk
+	for _, diskName := range diskNames {
+		disk, err := store.InspectDisk(diskName)
+		if err != nil {
+			logrus.WithError(err).Errorf("disk %q does not exist?", diskName)
+			continue
+		}
+		disks = append(disks, disk)
+	}
+
+	if jsonFormat {
+		for _, disk := range disks {
+			j, err := json.Marshal(disk)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), string(j))
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tDIR\tIN-USE-BY")
+	for _, disk := range disks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", disk.Name, units.BytesSize(float64(disk.Size)), disk.Dir, disk.Instance)
+	}
+	return w.Flush()
+}
+
+func newDiskDeleteCommand() *cobra.Command {
+	var diskDeleteCommand = &cobra.Command{
+		Use:               "delete DISK [DISK, ...]",
+		Aliases:           []string{"remove", "rm"},
+		Short:             "Delete additional disks",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              diskDeleteAction,
+		ValidArgsFunction: diskBashComplete,
+	}
+	diskDeleteCommand.Flags().BoolP("force", "f", false, "delete the disk even when it is in use by an instance")
+	return diskDeleteCommand
+}
+
+func diskDeleteAction(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	for _, diskName := range args {
+		disk, err := store.InspectDisk(diskName)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				logrus.Warnf("Ignoring non-existent disk %q", diskName)
+				continue
+			}
+			return err
+		}
+		if !force && disk.Instance != "" {
+			return fmt.Errorf("disk %q is in use by instance %q (run `limactl stop %s` first)", diskName, disk.Instance, disk.Instance)
+		}
+		if err := os.RemoveAll(disk.Dir); err != nil {
+			return fmt.Errorf("failed to remove %q: %w", disk.Dir, err)
+		}
+		logrus.Infof("Deleted %q (%q)", diskName, disk.Dir)
+	}
+	return nil
+}
+
+func diskBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	disks, _ := store.Disks()
+	return disks, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestSetYAMLDisk(t *testing.T) {
+	type testCase struct {
+		yaml     string
+		expected string // empty when the comments cannot be preserved
+	}
+	testCases := []testCase{
+		{yaml: "cpus: 2\ndisk: 10GiB\n", expected: "cpus: 2\ndisk: \"20GiB\"\n"},
+		{yaml: "# comment\ncpus: 2\ndisk: 10GiB # trailing comment\n# comment\n", expected: "# comment\ncpus: 2\ndisk: \"20GiB\" # trailing comment\n# comment\n"},
+		{yaml: "cpus: 2\n\"disk\": \"10GiB\" # comment\n", expected: "cpus: 2\ndisk: \"20GiB\" # comment\n"},
+		{yaml: "cpus: 2\ndisk: '10 # GiB'\n", expected: "cpus: 2\ndisk: \"20GiB\"\n"},
+		{yaml: "cpus: 2\ndisk: null\r\n", expected: "cpus: 2\ndisk: \"20GiB\"\r\n"},
+		{yaml: "cpus: 2\ndisk: # comment\n", expected: "cpus: 2\ndisk: \"20GiB\" # comment\n"},
+		{yaml: "# comment\ncpus: 2", expected: "# comment\ncpus: 2\ndisk: \"20GiB\"\n"},
+		{yaml: "", expected: "disk: \"20GiB\"\n"},
+		// a nested `disk` is not the top-level field
+		{yaml: "cpus: 2\nfoo:\n  disk: 10GiB\n", expected: "cpus: 2\nfoo:\n  disk: 10GiB\ndisk: \"20GiB\"\n"},
+		// cannot be edited in place
+		{yaml: "{cpus: 2, disk: 10GiB}\n"},
+		{yaml: "cpus: 2\ndisk:\n  10GiB\n"},
+	}
+	for _, tc := range testCases {
+		b, err := setYAMLDisk([]byte(tc.yaml), "20GiB")
+		assert.NilError(t, err)
+		if tc.expected != "" {
+			assert.Equal(t, tc.expected, string(b))
+		}
+		var y struct {
+			Disk string `yaml:"disk"`
+		}
+		assert.NilError(t, yaml.Unmarshal(b, &y), string(b))
+		assert.Equal(t, "20GiB", y.Disk, tc.yaml)
+	}
+}
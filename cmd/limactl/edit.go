@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const editExample = `
+  Open an editor:
+    $ limactl edit default
+
+  Modify the fields without opening an editor:
+    $ limactl edit --set cpus=8 --set memory=16GiB default
+`
+
+func newEditCommand() *cobra.Command {
+	var editCommand = &cobra.Command{
+		Use:               "edit INSTANCE",
+		Short:             "Edit the configuration of a stopped instance",
+		Example:           editExample,
+		Args:              cobra.ExactArgs(1),
+		RunE:              editAction,
+		ValidArgsFunction: editBashComplete,
+	}
+	editCommand.Flags().StringArray("set", nil, "set the field without opening an editor, in the form of KEY=VALUE (e.g., \"ssh.localPort=60022\"); VALUE is parsed as YAML")
+	return editCommand
+}
+
+func editAction(cmd *cobra.Command, args []string) error {
+	sets, err := cmd.Flags().GetStringArray("set")
+	if err != nil {
+		return err
+	}
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusStopped {
+		return fmt.Errorf("expected status %q, got %q (run `limactl stop %s` first)", store.StatusStopped, inst.Status, instName)
+	}
+
+	filePath := filepath.Join(inst.Dir, filenames.LimaYAML)
+	yBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	oldY, err := limayaml.Load(yBytes, filePath)
+	if err != nil {
+		return err
+	}
+
+	var interactive bool
+	if len(sets) > 0 {
+		yBytes, err = setYAMLFields(yBytes, sets)
+		if err != nil {
+			return err
+		}
+	} else {
+		if !isatty.IsTerminal(os.Stdout.Fd()) {
+			return errors.New("terminal is not available, use `--set KEY=VALUE` to edit the instance without opening an editor")
+		}
+		interactive = true
+		hdr := fmt.Sprintf("# Review and modify the following configuration for Lima instance %q.\n", instName)
+		hdr += "# - The changes take effect on the next start.\n"
+		hdr += "# - To cancel editing, just save this file as an empty file.\n"
+		yBytes, err = openEditor(cmd, instName, yBytes, hdr)
+		if err != nil {
+			return err
+		}
+		if len(yBytes) == 0 {
+			logrus.Info("Aborting, as requested by saving the file with empty content")
+			return nil
+		}
+	}
+
+	newY, err := limayaml.Load(yBytes, filePath)
+	if err != nil {
+		return err
+	}
+	if err := limayaml.Validate(*newY, true); err != nil {
+		if !interactive {
+			return err
+		}
+		rejectedYAML := "lima.REJECTED.yaml"
+		if writeErr := os.WriteFile(rejectedYAML, yBytes, 0644); writeErr != nil {
+			return fmt.Errorf("the YAML is invalid, attempted to save the buffer as %q but failed: %v: %w", rejectedYAML, writeErr, err)
+		}
+		return fmt.Errorf("the YAML is invalid, saved the buffer as %q: %w", rejectedYAML, err)
+	}
+
+	changes, err := effectiveChanges(oldY, newY)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		logrus.Infof("No effective changes to instance %q", instName)
+		// still write the file, as comments might have been modified
+	}
+	for _, c := range changes {
+		fmt.Fprintln(cmd.OutOrStdout(), c)
+	}
+	if err := os.WriteFile(filePath, yBytes, 0644); err != nil {
+		return err
+	}
+	logrus.Infof("Updated %q. The changes take effect on the next start.", filePath)
+	return nil
+}
+
+// setYAMLFields sets the fields specified as "KEY=VALUE".
+// KEY may be a dot-separated path such as "ssh.localPort".
+// VALUE is parsed as YAML, e.g., "8" is an integer and "[]" is an empty list.
+func setYAMLFields(yBytes []byte, sets []string) ([]byte, error) {
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(yBytes, &m); err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		kv := strings.SplitN(set, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("expected KEY=VALUE, got %q", set)
+		}
+		k, v := kv[0], kv[1]
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(v), &value); err != nil {
+			return nil, fmt.Errorf("failed to parse the value of %q: %w", k, err)
+		}
+		var err error
+		m, err = setMapSliceField(m, strings.Split(k, "."), value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set %q: %w", k, err)
+		}
+	}
+	logrus.Info("Setting the fields (comments in the YAML are not preserved)")
+	return yaml.Marshal(m)
+}
+
+func setMapSliceField(m yaml.MapSlice, path []string, value interface{}) (yaml.MapSlice, error) {
+	if len(path) == 0 || path[0] == "" {
+		return nil, errors.New("empty key")
+	}
+	for i := range m {
+		if m[i].Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			m[i].Value = value
+			return m, nil
+		}
+		child, ok := m[i].Value.(yaml.MapSlice)
+		if !ok && m[i].Value != nil {
+			return nil, fmt.Errorf("field %q is not a map", path[0])
+		}
+		child, err := setMapSliceField(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		m[i].Value = child
+		return m, nil
+	}
+	if len(path) == 1 {
+		return append(m, yaml.MapItem{Key: path[0], Value: value}), nil
+	}
+	child, err := setMapSliceField(nil, path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	return append(m, yaml.MapItem{Key: path[0], Value: child}), nil
+}
+
+// effectiveChanges compares the top-level fields of the YAMLs after filling the default values,
+// and returns the changes in the form of "FIELD: OLD -> NEW".
+func effectiveChanges(oldY, newY *limayaml.LimaYAML) ([]string, error) {
+	var changes []string
+	oldV, newV := reflect.ValueOf(*oldY), reflect.ValueOf(*newY)
+	t := oldV.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		oldField, newField := oldV.Field(i).Interface(), newV.Field(i).Interface()
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		oldJSON, err := json.Marshal(oldField)
+		if err != nil {
+			return nil, err
+		}
+		newJSON, err := json.Marshal(newField)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, oldJSON, newJSON))
+	}
+	return changes, nil
+}
+
+func editBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestSetMapSliceField(t *testing.T) {
+	type testCase struct {
+		yaml     string
+		path     []string
+		value    interface{}
+		expected string
+		err      bool
+	}
+	testCases := []testCase{
+		{yaml: "cpus: 2\n", path: []string{"cpus"}, value: 4, expected: "cpus: 4\n"},
+		{yaml: "cpus: 2\n", path: []string{"memory"}, value: "8GiB", expected: "cpus: 2\nmemory: 8GiB\n"},
+		{yaml: "ssh:\n  localPort: 60022\n  forwardAgent: true\n", path: []string{"ssh", "localPort"}, value: 0, expected: "ssh:\n  localPort: 0\n  forwardAgent: true\n"},
+		{yaml: "ssh:\n  forwardAgent: true\n", path: []string{"ssh", "localPort"}, value: 0, expected: "ssh:\n  forwardAgent: true\n  localPort: 0\n"},
+		{yaml: "ssh:\n", path: []string{"ssh", "localPort"}, value: 0, expected: "ssh:\n  localPort: 0\n"},
+		{yaml: "cpus: 2\n", path: []string{"video", "display"}, value: "none", expected: "cpus: 2\nvideo:\n  display: none\n"},
+		{yaml: "cpus: 2\n", path: []string{"cpus", "foo"}, value: 4, err: true},
+		{yaml: "cpus: 2\n", path: []string{"ssh", ""}, value: 4, err: true},
+		{yaml: "cpus: 2\n", path: []string{}, value: 4, err: true},
+	}
+	for _, tc := range testCases {
+		var m yaml.MapSlice
+		assert.NilError(t, yaml.Unmarshal([]byte(tc.yaml), &m))
+		m, err := setMapSliceField(m, tc.path, tc.value)
+		if tc.err {
+			assert.Assert(t, err != nil, tc.path)
+			continue
+		}
+		assert.NilError(t, err, tc.path)
+		b, err := yaml.Marshal(m)
+		assert.NilError(t, err)
+		assert.Equal(t, tc.expected, string(b))
+	}
+}
+
+func TestSetYAMLFields(t *testing.T) {
+	b, err := setYAMLFields([]byte("cpus: 2\n"), []string{"cpus=4", "ssh.localPort=0", "mounts=[]"})
+	assert.NilError(t, err)
+	assert.Equal(t, "cpus: 4\nssh:\n  localPort: 0\nmounts: []\n", string(b))
+
+	_, err = setYAMLFields([]byte("cpus: 2\n"), []string{"cpus"})
+	assert.ErrorContains(t, err, "expected KEY=VALUE")
+}
+
+func TestEffectiveChanges(t *testing.T) {
+	oldY := &limayaml.LimaYAML{CPUs: 2, Memory: "4GiB"}
+
+	changes, err := effectiveChanges(oldY, &limayaml.LimaYAML{CPUs: 2, Memory: "4GiB"})
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(changes))
+
+	changes, err = effectiveChanges(oldY, &limayaml.LimaYAML{CPUs: 4, Memory: "4GiB"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"cpus: 2 -> 4"}, changes)
+
+	changes, err = effectiveChanges(oldY, &limayaml.LimaYAML{CPUs: 4, Memory: "8GiB"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"cpus: 2 -> 4", `memory: "4GiB" -> "8GiB"`}, changes)
+}
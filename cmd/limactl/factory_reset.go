@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lima-vm/lima/pkg/iso9660util"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newFactoryResetCommand() *cobra.Command {
+	var resetCommand = &cobra.Command{
+		Use:   "factory-reset INSTANCE",
+		Short: "Reset an instance to its pristine base image",
+		Long: `Reset an instance to its pristine base image.
+
+The changes to the disk of the instance (including the snapshots), cidata.iso, and the logs are discarded.
+lima.yaml is kept, so the next "limactl start" boots the instance with the same configuration.
+The additional disks ("additionalDisks" in lima.yaml) are not reset.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              factoryResetAction,
+		ValidArgsFunction: factoryResetBashComplete,
+	}
+	return resetCommand
+}
+
+func factoryResetAction(cmd *cobra.Command, args []string) error {
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusStopped {
+		return fmt.Errorf("expected status %q, got %q (run `limactl stop %s` first)", store.StatusStopped, inst.Status, instName)
+	}
+	if err := discardSavedState(inst); err != nil {
+		return err
+	}
+	if err := unlockDisks(inst); err != nil {
+		return err
+	}
+
+	// The basedisk is the backing file of the diffdisk and is never modified, so start.Start recreates the diffdisk from it via qemu.EnsureDisk.
+	// Without the diffdisk (`disk: 0`), the basedisk is modified unless it is an ISO9660 image, so it has to be copied from the cache again.
+	keepBaseDisk := true
+	if _, err := os.Stat(filepath.Join(inst.Dir, filenames.DiffDisk)); errors.Is(err, os.ErrNotExist) {
+		keepBaseDisk, _ = iso9660util.IsISO9660(filepath.Join(inst.Dir, filenames.BaseDisk))
+	}
+
+	// Only the files created by `limactl start` are removed; unknown files are left alone.
+	names := []string{filenames.DiffDisk, filenames.CIDataISO, filenames.VMState, filenames.SuspendedMarker, filenames.AnsibleInventoryYAML}
+	if !keepBaseDisk {
+		names = append(names, filenames.BaseDisk)
+	}
+	logs, err := filepath.Glob(filepath.Join(inst.Dir, "*.log"))
+	if err != nil {
+		return err
+	}
+	for _, f := range logs {
+		names = append(names, filepath.Base(f))
+	}
+	for _, name := range names {
+		path := filepath.Join(inst.Dir, name)
+		logrus.Debugf("Removing %q", path)
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("Instance %q has been reset. Run `limactl start %s` to boot the instance from scratch.", instName, instName)
+	return nil
+}
+
+func factoryResetBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
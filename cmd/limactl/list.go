@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"text/tabwriter"
+	"time"
 
 	"github.com/docker/go-units"
 	"github.com/lima-vm/lima/pkg/store"
@@ -61,6 +62,10 @@ func listAction(cmd *cobra.Command, args []string) error {
 				logrus.WithError(err).Errorf("instance %q does not exist?", instName)
 				continue
 			}
+			// The failure is not fatal, as qemu-img might not be installed (e.g., on a machine without QEMU)
+			if err := inst.LoadSnapshots(); err != nil {
+				logrus.WithError(err).Debugf("failed to list the snapshots of instance %q", instName)
+			}
 			b, err := json.Marshal(inst)
 			if err != nil {
 				return err
@@ -86,6 +91,12 @@ func listAction(cmd *cobra.Command, args []string) error {
 		if len(inst.Errors) > 0 {
 			logrus.WithField("errors", inst.Errors).Warnf("instance %q has errors", instName)
 		}
+		for _, probe := range inst.Probes {
+			if !probe.Healthy && !probe.LastChecked.IsZero() {
+				logrus.WithField("error", probe.Error).Warnf("instance %q is failing the liveness probe %q (last checked at %s)",
+					instName, probe.Description, probe.LastChecked.Format(time.RFC3339))
+			}
+		}
 		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
 			inst.Name,
 			inst.Status,
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"github.com/lima-vm/lima/pkg/logrusutil"
+	"github.com/lima-vm/lima/pkg/sshutil"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/nxadm/tail"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	logSourceHostAgent  = "hostagent"
+	logSourceSerial     = "serial"
+	logSourceGuestAgent = "guestagent"
+	logSourceCloudInit  = "cloud-init"
+)
+
+var logSources = []string{logSourceHostAgent, logSourceSerial, logSourceGuestAgent, logSourceCloudInit}
+
+func newLogsCommand() *cobra.Command {
+	var logsCommand = &cobra.Command{
+		Use:   "logs INSTANCE",
+		Short: "Show the logs of an instance",
+		Long: `Show the logs of an instance.
+
+Sources:
+- hostagent:  the log of the host agent ("ha.stderr.log" in the instance directory)
+- serial:     the serial console ("serial.log" in the instance directory)
+- guestagent: the journal of the guest agent (requires the instance to be running)
+- cloud-init: the output of cloud-init, including the boot scripts and the provisioning scripts (requires the instance to be running)`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              logsAction,
+		ValidArgsFunction: logsBashComplete,
+	}
+	logsCommand.Flags().String("source", logSourceHostAgent, fmt.Sprintf("log source, one of %v", logSources))
+	logsCommand.Flags().BoolP("follow", "f", false, "follow the log output")
+	logsCommand.Flags().Duration("since", 0, "show the logs newer than the relative duration, e.g., 10m (only for hostagent and guestagent)")
+	return logsCommand
+}
+
+func logsAction(cmd *cobra.Command, args []string) error {
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		return err
+	}
+	follow, err := cmd.Flags().GetBool("follow")
+	if err != nil {
+		return err
+	}
+	since, err := cmd.Flags().GetDuration("since")
+	if err != nil {
+		return err
+	}
+	if since < 0 {
+		return fmt.Errorf("--since must not be negative, got %v", since)
+	}
+	var begin time.Time
+	if since > 0 {
+		begin = time.Now().Add(-since)
+	}
+
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+
+	ctx := cmd.Context()
+	switch source {
+	case logSourceHostAgent:
+		logger := logrus.New()
+		logger.SetOutput(cmd.OutOrStdout())
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+		logger.SetLevel(logrus.TraceLevel)
+		return tailLogFile(ctx, filepath.Join(inst.Dir, filenames.HostAgentStderrLog), follow, func(line string) {
+			logrusutil.PropagateJSON(logger, []byte(line), "", begin)
+		})
+	case logSourceSerial:
+		if since > 0 {
+			return fmt.Errorf("--since is not supported for source %q", source)
+		}
+		w := cmd.OutOrStdout()
+		return tailLogFile(ctx, filepath.Join(inst.Dir, filenames.SerialLog), follow, func(line string) {
+			fmt.Fprintln(w, line)
+		})
+	case logSourceGuestAgent:
+		// The guest agent is a systemd system service, see cmd/lima-guestagent/install_systemd_linux.go
+		script := "sudo journalctl --no-pager -u lima-guestagent"
+		if since > 0 {
+			script += " --since " + shellescape.Quote("@"+strconv.FormatInt(begin.Unix(), 10))
+		}
+		if follow {
+			script += " --follow"
+		}
+		return runGuestLogCommand(ctx, cmd, inst, script)
+	case logSourceCloudInit:
+		if since > 0 {
+			return fmt.Errorf("--since is not supported for source %q", source)
+		}
+		script := "sudo tail -n +1 /var/log/cloud-init-output.log"
+		if follow {
+			script = "sudo tail -n +1 -F /var/log/cloud-init-output.log"
+		}
+		return runGuestLogCommand(ctx, cmd, inst, script)
+	default:
+		return fmt.Errorf("unknown source %q, must be one of %v", source, logSources)
+	}
+}
+
+// tailLogFile calls onLine for each line of the file, and keeps waiting for new lines if follow is true.
+func tailLogFile(ctx context.Context, path string, follow bool, onLine func(string)) error {
+	t, err := tail.TailFile(path,
+		tail.Config{
+			Follow:    follow,
+			ReOpen:    follow,
+			MustExist: true,
+			Logger:    tail.DiscardingLogger,
+		})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = t.Stop()
+		t.Cleanup()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-t.Lines:
+			if !ok {
+				return t.Wait()
+			}
+			if line.Err != nil {
+				logrus.Error(line.Err)
+				continue
+			}
+			onLine(line.Text)
+		}
+	}
+}
+
+// runGuestLogCommand executes the script in the guest via SSH, and prints its output.
+func runGuestLogCommand(ctx context.Context, cmd *cobra.Command, inst *store.Instance, script string) error {
+	if inst.Status != store.StatusRunning {
+		return fmt.Errorf("expected status %q, got %q", store.StatusRunning, inst.Status)
+	}
+	y, err := inst.LoadYAML()
+	if err != nil {
+		return err
+	}
+	arg0, err := exec.LookPath("ssh")
+	if err != nil {
+		return err
+	}
+	sshOpts, err := sshutil.SSHOpts(inst.Dir, *y.SSH.LoadDotSSHPubKeys, *y.SSH.ForwardAgent)
+	if err != nil {
+		return err
+	}
+	sshArgs := sshutil.SSHArgsFromOpts(sshOpts)
+	sshArgs = append(sshArgs, "-q", "-p", strconv.Itoa(inst.SSHLocalPort), "127.0.0.1", "--", script)
+	sshCmd := exec.CommandContext(ctx, arg0, sshArgs...)
+	sshCmd.Stdout = cmd.OutOrStdout()
+	sshCmd.Stderr = cmd.ErrOrStderr()
+	logrus.Debugf("executing ssh: %+v", sshCmd.Args)
+	return sshCmd.Run()
+}
+
+func logsBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
@@ -81,6 +81,17 @@ func newApp() *cobra.Command {
 		newHostagentCommand(),
 		newInfoCommand(),
 		newShowSSHCommand(),
+		newSnapshotCommand(),
+		newCloneCommand(),
+		newDiskCommand(),
+		newEditCommand(),
+		newPortForwardCommand(),
+		newProvisionCommand(),
+		newLogsCommand(),
+		newConsoleCommand(),
+		newSuspendCommand(),
+		newResumeCommand(),
+		newFactoryResetCommand(),
 	)
 	return rootCmd
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	hostagentclient "github.com/lima-vm/lima/pkg/hostagent/api/client"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const portForwardExample = `
+  Forward the guest port 8080 to 127.0.0.1:8080 on the host:
+    $ limactl port-forward default add 8080
+
+  Forward the guest port 80 to 0.0.0.0:8080 on the host, so that other hosts can connect to it:
+    $ limactl port-forward default add 0.0.0.0:8080:80
+
+  Forward the guest UDP port 5353 to 127.0.0.1:5353 on the host:
+    $ limactl port-forward default add 5353/udp
+
+  Remove the rule:
+    $ limactl port-forward default remove 0.0.0.0:8080:80
+
+  List the active forwards:
+    $ limactl port-forward default list
+`
+
+func newPortForwardCommand() *cobra.Command {
+	var portForwardCommand = &cobra.Command{
+		Use:   "port-forward INSTANCE (add|remove|list) [[HOST_IP:][HOST_PORT:]GUEST_PORT[/PROTO]]",
+		Short: "Manage port forwards of a running instance",
+		Long: `Manage port forwards of a running instance.
+The rules added with this command are not persisted, and are lost when the instance is stopped.
+Edit "portForwards" in lima.yaml for persistent rules.`,
+		Example:           portForwardExample,
+		Args:              cobra.RangeArgs(2, 3),
+		RunE:              portForwardAction,
+		ValidArgsFunction: portForwardBashComplete,
+	}
+	portForwardCommand.Flags().String("guest-ip", "", "guest IP address (default: 127.0.0.1)")
+	return portForwardCommand
+}
+
+func portForwardAction(cmd *cobra.Command, args []string) error {
+	instName, action := args[0], args[1]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusRunning {
+		return fmt.Errorf("expected status %q, got %q", store.StatusRunning, inst.Status)
+	}
+	haSock := filepath.Join(inst.Dir, filenames.HostAgentSock)
+	haClient, err := hostagentclient.NewHostAgentClient(haSock)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	switch action {
+	case "list", "ls":
+		if len(args) != 2 {
+			return fmt.Errorf("%q does not take arguments", action)
+		}
+		ports, err := haClient.Ports(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
+		fmt.Fprintln(w, "PROTO\tGUEST\tHOST")
+		for _, p := range ports {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", p.Protocol, p.GuestAddress, p.HostAddress)
+		}
+		return w.Flush()
+	case "add", "remove", "rm":
+		if len(args) != 3 {
+			return fmt.Errorf("%q requires [HOST_IP:][HOST_PORT:]GUEST_PORT[/PROTO]", action)
+		}
+		rule, err := parsePortForward(args[2])
+		if err != nil {
+			return err
+		}
+		guestIP, err := cmd.Flags().GetString("guest-ip")
+		if err != nil {
+			return err
+		}
+		if guestIP != "" {
+			rule.GuestIP = net.ParseIP(guestIP)
+			if rule.GuestIP == nil {
+				return fmt.Errorf("invalid guest IP %q", guestIP)
+			}
+		}
+		if action == "add" {
+			if err := haClient.AddPortForward(ctx, rule); err != nil {
+				return err
+			}
+			logrus.Infof("Added the port forwarding rule %q", args[2])
+			return nil
+		}
+		if err := haClient.RemovePortForward(ctx, rule); err != nil {
+			return err
+		}
+		logrus.Infof("Removed the port forwarding rule %q", args[2])
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q, expected \"add\", \"remove\", or \"list\"", action)
+	}
+}
+
+// parsePortForward parses "[HOST_IP:][HOST_PORT:]GUEST_PORT[/PROTO]".
+// HOST_IP may be an IPv6 address enclosed in brackets, e.g., "[::1]:8080:80".
+func parsePortForward(s string) (limayaml.PortForward, error) {
+	var rule limayaml.PortForward
+	spec := s
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		rule.Proto = limayaml.Proto(spec[i+1:])
+		spec = spec[:i]
+	}
+	var hostIP string
+	if i := strings.LastIndex(spec, "]:"); strings.HasPrefix(spec, "[") && i >= 0 {
+		hostIP, spec = spec[1:i], spec[i+2:]
+	}
+	parts := strings.Split(spec, ":")
+	switch len(parts) {
+	case 1:
+	case 2:
+		if hostIP == "" && net.ParseIP(parts[0]) != nil {
+			hostIP, parts = parts[0], parts[1:]
+		}
+	case 3:
+		if hostIP != "" {
+			return rule, fmt.Errorf("invalid port forward %q", s)
+		}
+		hostIP, parts = parts[0], parts[1:]
+	default:
+		return rule, fmt.Errorf("invalid port forward %q", s)
+	}
+	if hostIP != "" {
+		rule.HostIP = net.ParseIP(hostIP)
+		if rule.HostIP == nil {
+			return rule, fmt.Errorf("invalid host IP %q in %q", hostIP, s)
+		}
+	}
+	var err error
+	rule.GuestPort, err = strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return rule, fmt.Errorf("invalid guest port in %q: %w", s, err)
+	}
+	if len(parts) == 2 {
+		rule.HostPort, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return rule, fmt.Errorf("invalid host port in %q: %w", s, err)
+		}
+	}
+	return rule, nil
+}
+
+func portForwardBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return bashCompleteInstanceNames(cmd)
+	case 1:
+		return []string{"add", "remove", "list"}, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePortForward(t *testing.T) {
+	type testCase struct {
+		s        string
+		expected limayaml.PortForward
+		err      bool
+	}
+	testCases := []testCase{
+		{s: "80", expected: limayaml.PortForward{GuestPort: 80}},
+		{s: "8080:80", expected: limayaml.PortForward{HostPort: 8080, GuestPort: 80}},
+		{s: "53/udp", expected: limayaml.PortForward{GuestPort: 53, Proto: limayaml.UDP}},
+		{s: "127.0.0.2:80", expected: limayaml.PortForward{HostIP: net.ParseIP("127.0.0.2"), GuestPort: 80}},
+		{s: "0.0.0.0:8080:80/tcp", expected: limayaml.PortForward{HostIP: net.IPv4zero, HostPort: 8080, GuestPort: 80, Proto: limayaml.TCP}},
+		{s: "[::1]:8080:80", expected: limayaml.PortForward{HostIP: net.IPv6loopback, HostPort: 8080, GuestPort: 80}},
+		{s: "[::1]:80", expected: limayaml.PortForward{HostIP: net.IPv6loopback, GuestPort: 80}},
+		{s: "", err: true},
+		{s: "foo", err: true},
+		{s: "foo:80", err: true},
+		{s: "8080:foo", err: true},
+		{s: "localhost:8080:80", err: true},
+		{s: "[::1]:127.0.0.1:8080:80", err: true},
+		{s: "1:2:3:4", err: true},
+	}
+	for _, tc := range testCases {
+		rule, err := parsePortForward(tc.s)
+		if tc.err {
+			assert.Assert(t, err != nil, tc.s)
+			continue
+		}
+		assert.NilError(t, err, tc.s)
+		assert.DeepEqual(t, tc.expected, rule)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/lima-vm/lima/pkg/ansible"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/sshutil"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newProvisionCommand() *cobra.Command {
+	var provisionCommand = &cobra.Command{
+		Use:   "provision INSTANCE",
+		Short: "Re-run the provisioning scripts of a running instance",
+		Long: `Re-run the provisioning scripts of a running instance.
+The scripts with "when: once" are skipped if they have already succeeded, unless --force is specified.
+The playbooks of "mode: ansible" are executed with ansible-playbook on the host.
+The scripts are loaded on starting the instance, so the instance has to be restarted after modifying lima.yaml.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              provisionAction,
+		ValidArgsFunction: provisionBashComplete,
+	}
+	provisionCommand.Flags().Bool("force", false, "re-run the scripts with \"when: once\" too")
+	return provisionCommand
+}
+
+// cidataMnt is LIMA_CIDATA_MNT in cidata.TEMPLATE.d/user-data
+const cidataMnt = "/mnt/lima-cidata"
+
+// provisionSkippedExitCode is the exit code of cidata.TEMPLATE.d/provision.sh when the script is skipped
+const provisionSkippedExitCode = 3
+
+func provisionAction(cmd *cobra.Command, args []string) error {
+	force, err := cmd.Flags().GetBool("force")
+	if err != nil {
+		return err
+	}
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusRunning {
+		return fmt.Errorf("expected status %q, got %q", store.StatusRunning, inst.Status)
+	}
+	// The scripts in cidata.iso are generated from lima.yaml on starting the instance
+	yamlStat, err := os.Stat(filepath.Join(inst.Dir, filenames.LimaYAML))
+	if err != nil {
+		return err
+	}
+	cidataStat, err := os.Stat(filepath.Join(inst.Dir, filenames.CIDataISO))
+	if err != nil {
+		return err
+	}
+	if yamlStat.ModTime().After(cidataStat.ModTime()) {
+		return fmt.Errorf("%q has been modified since instance %q was started, run `limactl stop %s` and `limactl start %s` to apply the changes",
+			filenames.LimaYAML, instName, instName, instName)
+	}
+	y, err := inst.LoadYAML()
+	if err != nil {
+		return err
+	}
+	// The indices of the scripts in cidata.iso are the positions in the order of execution
+	order, err := limayaml.ProvisionOrder(y.Provision)
+	if err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		logrus.Infof("Instance %q has no provisioning script", instName)
+		return nil
+	}
+
+	arg0, err := exec.LookPath("ssh")
+	if err != nil {
+		return err
+	}
+	sshOpts, err := sshutil.SSHOpts(inst.Dir, *y.SSH.LoadDotSSHPubKeys, *y.SSH.ForwardAgent)
+	if err != nil {
+		return err
+	}
+	sshArgs := sshutil.SSHArgsFromOpts(sshOpts)
+	sshArgs = append(sshArgs, "-q", "-p", strconv.Itoa(inst.SSHLocalPort), "127.0.0.1", "--",
+		"sudo", cidataMnt+"/provision.sh")
+	if force {
+		sshArgs = append(sshArgs, "--force")
+	}
+
+	statuses := make([]string, len(order))
+	var (
+		failed        int
+		guestIndex    int // the index in cidata.iso, which does not contain "ansible"
+		inventoryPath string
+	)
+	for j, i := range order {
+		p := y.Provision[i]
+		var c *exec.Cmd
+		if p.Mode == limayaml.ProvisionModeAnsible {
+			if inventoryPath == "" {
+				inventoryPath, err = ansible.WriteInventory(inst.Dir, inst.Name, inst.SSHLocalPort, sshutil.SSHArgsFromOpts(sshOpts))
+				if err != nil {
+					return err
+				}
+			}
+			c, err = ansible.PlaybookCommand(cmd.Context(), inventoryPath, p.Playbook)
+			if err != nil {
+				return err
+			}
+		} else {
+			c = exec.Command(arg0, append(sshArgs, strconv.Itoa(guestIndex))...)
+			guestIndex++
+		}
+		// The output of the scripts is printed to stderr, so that stdout only contains the summary
+		c.Stdout = cmd.ErrOrStderr()
+		c.Stderr = cmd.ErrOrStderr()
+		logrus.Debugf("executing provision %q: %+v", p.Name, c.Args)
+		if err := c.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				return err
+			}
+			if p.Mode != limayaml.ProvisionModeAnsible && exitErr.ExitCode() == provisionSkippedExitCode {
+				statuses[j] = "skipped"
+				continue
+			}
+			statuses[j] = fmt.Sprintf("failed (exit status %d)", exitErr.ExitCode())
+			failed++
+			continue
+		}
+		statuses[j] = "ok"
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMODE\tWHEN\tSTATUS")
+	for j, i := range order {
+		p := y.Provision[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, p.Mode, p.When, statuses[j])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d provisioning scripts failed", failed, len(order))
+	}
+	return nil
+}
+
+func provisionBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	networks "github.com/lima-vm/lima/pkg/networks/reconcile"
+	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/start"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newResumeCommand() *cobra.Command {
+	var resumeCommand = &cobra.Command{
+		Use:               "resume INSTANCE",
+		Short:             "Resume an instance suspended with `limactl suspend`",
+		Args:              cobra.ExactArgs(1),
+		RunE:              resumeAction,
+		ValidArgsFunction: resumeBashComplete,
+	}
+	return resumeCommand
+}
+
+func resumeAction(cmd *cobra.Command, args []string) error {
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	if inst.Status != store.StatusSuspended {
+		return fmt.Errorf("expected status %q, got %q", store.StatusSuspended, inst.Status)
+	}
+	if inst.QemuPID == 0 {
+		// The state was saved to disk with `limactl suspend --save`, and is restored by the hostagent
+		logrus.Infof("Restoring the instance %q from the saved state", instName)
+		ctx := cmd.Context()
+		if err := networks.Reconcile(ctx, inst.Name); err != nil {
+			return err
+		}
+		return start.Start(ctx, inst)
+	}
+	if err := qemu.Resume(qemuConfig(inst)); err != nil {
+		return err
+	}
+	logrus.Infof("Resumed. Run `%s` to open the shell.", start.LimactlShellCmd(instName))
+	return nil
+}
+
+func resumeBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
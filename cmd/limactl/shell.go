@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/alessio/shellescape"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/localpathutil"
 	"github.com/lima-vm/lima/pkg/sshutil"
 	"github.com/lima-vm/lima/pkg/store"
+	"github.com/lima-vm/lima/pkg/store/filenames"
 	"github.com/mattn/go-isatty"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -36,7 +41,7 @@ func newShellCommand() *cobra.Command {
 
 	shellCmd.Flags().SetInterspersed(false)
 
-	shellCmd.Flags().String("workdir", "", "working directory")
+	shellCmd.Flags().String("workdir", "", "working directory (default: `workDir` in lima.yaml, or the guest path of the current directory)")
 	return shellCmd
 }
 
@@ -68,6 +73,9 @@ func shellAction(cmd *cobra.Command, args []string) error {
 	if inst.Status == store.StatusStopped {
 		return fmt.Errorf("instance %q is stopped, run `limactl start %s` to start the instance", instName, instName)
 	}
+	if inst.Status == store.StatusSuspended {
+		return fmt.Errorf("instance %q is suspended, run `limactl resume %s` to resume the instance", instName, instName)
+	}
 	y, err := inst.LoadYAML()
 	if err != nil {
 		return err
@@ -75,32 +83,45 @@ func shellAction(cmd *cobra.Command, args []string) error {
 
 	// When workDir is explicitly set, the shell MUST have workDir as the cwd, or exit with an error.
 	//
-	// changeDirCmd := "cd workDir || exit 1"                  if workDir != ""
-	//              := "cd hostCurrentDir || cd hostHomeDir"   if workDir == ""
+	// changeDirCmd := "cd workDir || exit 1"                   if workDir != ""
+	//              := "cd guestCurrentDir || cd guestHomeDir"  if workDir == ""
+	//
+	// guestCurrentDir and guestHomeDir are the guest paths of the host cwd and the host home,
+	// translated with `mounts` in lima.yaml. They are omitted when the host paths are not mounted.
 	var changeDirCmd string
 	workDir, err := cmd.Flags().GetString("workdir")
 	if err != nil {
 		return err
 	}
+	if workDir == "" {
+		workDir = y.WorkDir
+	}
 	if workDir != "" {
 		changeDirCmd = fmt.Sprintf("cd %q || exit 1", workDir)
-		// FIXME: check whether y.Mounts contains the home, not just len > 0
-	} else if len(y.Mounts) > 0 {
+	} else {
+		var cmds []string
 		hostCurrentDir, err := os.Getwd()
 		if err == nil {
-			changeDirCmd = fmt.Sprintf("cd %q", hostCurrentDir)
+			if guestCurrentDir, ok := guestPath(y, hostCurrentDir); ok {
+				cmds = append(cmds, fmt.Sprintf("cd %q", guestCurrentDir))
+			} else {
+				logrus.Warnf("the current directory %q is not shared with the guest (see `mounts` in %q), so the guest shell will have a different cwd",
+					hostCurrentDir, filepath.Join(inst.Dir, filenames.LimaYAML))
+			}
 		} else {
-			changeDirCmd = "false"
 			logrus.WithError(err).Warn("failed to get the current directory")
 		}
 		hostHomeDir, err := os.UserHomeDir()
 		if err == nil {
-			changeDirCmd = fmt.Sprintf("%s || cd %q", changeDirCmd, hostHomeDir)
+			if guestHomeDir, ok := guestPath(y, hostHomeDir); ok {
+				cmds = append(cmds, fmt.Sprintf("cd %q", guestHomeDir))
+			} else {
+				logrus.Debug("the host home does not seem mounted, so the guest shell will have a different cwd")
+			}
 		} else {
 			logrus.WithError(err).Warn("failed to get the home directory")
 		}
-	} else {
-		logrus.Debug("the host home does not seem mounted, so the guest shell will have a different cwd")
+		changeDirCmd = strings.Join(cmds, " || ")
 	}
 
 	if changeDirCmd == "" {
@@ -144,6 +165,24 @@ func shellAction(cmd *cobra.Command, args []string) error {
 	return sshCmd.Run()
 }
 
+// guestPath translates the host path to the guest path, using `mountPoint` of the innermost mount that contains the host path.
+// ok is false when no mount contains the host path.
+func guestPath(y *limayaml.LimaYAML, hostPath string) (res string, ok bool) {
+	longest := -1
+	for _, f := range y.Mounts {
+		location, err := localpathutil.Expand(f.Location)
+		if err != nil || len(location) <= longest {
+			continue
+		}
+		rel, err := filepath.Rel(location, hostPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		res, longest = path.Join(f.MountPoint, filepath.ToSlash(rel)), len(location)
+	}
+	return res, longest >= 0
+}
+
 func shellBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	return bashCompleteInstanceNames(cmd)
 }
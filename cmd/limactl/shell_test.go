@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestGuestPath(t *testing.T) {
+	y := &limayaml.LimaYAML{
+		Mounts: []limayaml.Mount{
+			{Location: "/Users/foo", MountPoint: "/Users/foo"},
+			{Location: "/Users/foo/src/project", MountPoint: "/mnt/project"},
+			{Location: "/Users/foo/src", MountPoint: "/Users/foo/src"},
+			{Location: "/tmp/lima", MountPoint: "/tmp/lima"},
+		},
+	}
+	type testCase struct {
+		hostPath string
+		expected string
+		ok       bool
+	}
+	testCases := []testCase{
+		{hostPath: "/Users/foo", expected: "/Users/foo", ok: true},
+		{hostPath: "/Users/foo/Documents", expected: "/Users/foo/Documents", ok: true},
+		// the innermost mount takes precedence, regardless of the order of the mounts
+		{hostPath: "/Users/foo/src/other", expected: "/Users/foo/src/other", ok: true},
+		{hostPath: "/Users/foo/src/project", expected: "/mnt/project", ok: true},
+		{hostPath: "/Users/foo/src/project/cmd", expected: "/mnt/project/cmd", ok: true},
+		// a sibling with the same prefix is not contained
+		{hostPath: "/Users/foo/src/project2", expected: "/Users/foo/src/project2", ok: true},
+		{hostPath: "/Users/foobar", ok: false},
+		{hostPath: "/Users", ok: false},
+		{hostPath: "/tmp", ok: false},
+		{hostPath: "/tmp/lima/a/b", expected: "/tmp/lima/a/b", ok: true},
+	}
+	for _, tc := range testCases {
+		res, ok := guestPath(y, tc.hostPath)
+		assert.Equal(t, tc.ok, ok, tc.hostPath)
+		assert.Equal(t, tc.expected, res, tc.hostPath)
+	}
+}
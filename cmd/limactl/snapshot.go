@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/containerd/containerd/identifiers"
+	"github.com/docker/go-units"
+	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotCommand() *cobra.Command {
+	var snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Manage instance snapshots",
+	}
+	snapshotCmd.AddCommand(newSnapshotCreateCommand())
+	snapshotCmd.AddCommand(newSnapshotApplyCommand())
+	snapshotCmd.AddCommand(newSnapshotDeleteCommand())
+	snapshotCmd.AddCommand(newSnapshotListCommand())
+	return snapshotCmd
+}
+
+func newSnapshotCreateCommand() *cobra.Command {
+	var createCmd = &cobra.Command{
+		Use:               "create INSTANCE",
+		Aliases:           []string{"save"},
+		Short:             "Create (save) a snapshot",
+		Args:              cobra.ExactArgs(1),
+		RunE:              snapshotCreateAction,
+		ValidArgsFunction: snapshotBashComplete,
+	}
+	createCmd.Flags().String("tag", "", "name of the snapshot")
+	return createCmd
+}
+
+func snapshotCreateAction(cmd *cobra.Command, args []string) error {
+	inst, tag, err := snapshotInstanceAndTag(cmd, args)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Creating snapshot %q of instance %q", tag, inst.Name)
+	return qemu.SaveSnapshot(qemuConfig(inst), tag, inst.Status == store.StatusRunning)
+}
+
+func newSnapshotApplyCommand() *cobra.Command {
+	var applyCmd = &cobra.Command{
+		Use:               "apply INSTANCE",
+		Aliases:           []string{"load"},
+		Short:             "Apply (load) a snapshot",
+		Args:              cobra.ExactArgs(1),
+		RunE:              snapshotApplyAction,
+		ValidArgsFunction: snapshotBashComplete,
+	}
+	applyCmd.Flags().String("tag", "", "name of the snapshot")
+	return applyCmd
+}
+
+func snapshotApplyAction(cmd *cobra.Command, args []string) error {
+	inst, tag, err := snapshotInstanceAndTag(cmd, args)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Applying snapshot %q to instance %q", tag, inst.Name)
+	return qemu.LoadSnapshot(qemuConfig(inst), tag, inst.Status == store.StatusRunning)
+}
+
+func newSnapshotDeleteCommand() *cobra.Command {
+	var deleteCmd = &cobra.Command{
+		Use:               "delete INSTANCE",
+		Aliases:           []string{"del"},
+		Short:             "Delete (del) a snapshot",
+		Args:              cobra.ExactArgs(1),
+		RunE:              snapshotDeleteAction,
+		ValidArgsFunction: snapshotBashComplete,
+	}
+	deleteCmd.Flags().String("tag", "", "name of the snapshot")
+	return deleteCmd
+}
+
+func snapshotDeleteAction(cmd *cobra.Command, args []string) error {
+	inst, tag, err := snapshotInstanceAndTag(cmd, args)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Deleting snapshot %q of instance %q", tag, inst.Name)
+	return qemu.DeleteSnapshot(qemuConfig(inst), tag, inst.Status == store.StatusRunning)
+}
+
+func newSnapshotListCommand() *cobra.Command {
+	var listCmd = &cobra.Command{
+		Use:               "list INSTANCE",
+		Aliases:           []string{"ls"},
+		Short:             "List existing snapshots",
+		Args:              cobra.ExactArgs(1),
+		RunE:              snapshotListAction,
+		ValidArgsFunction: snapshotBashComplete,
+	}
+	listCmd.Flags().BoolP("quiet", "q", false, "Only show tags")
+	return listCmd
+}
+
+func snapshotListAction(cmd *cobra.Command, args []string) error {
+	quiet, err := cmd.Flags().GetBool("quiet")
+	if err != nil {
+		return err
+	}
+	inst, err := snapshotInstance(args[0])
+	if err != nil {
+		return err
+	}
+	snapshots, err := qemu.ListSnapshots(qemuConfig(inst))
+	if err != nil {
+		return err
+	}
+	if quiet {
+		for _, snapshot := range snapshots {
+			fmt.Fprintln(cmd.OutOrStdout(), snapshot.Name)
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 4, 8, 4, ' ', 0)
+	fmt.Fprintln(w, "ID\tTAG\tVM SIZE\tDATE")
+	for _, snapshot := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			snapshot.ID,
+			snapshot.Name,
+			units.BytesSize(float64(snapshot.VMStateSize)),
+			time.Unix(snapshot.DateSec, snapshot.DateNsec).Format(time.RFC3339),
+		)
+	}
+	return w.Flush()
+}
+
+func snapshotInstance(instName string) (*store.Instance, error) {
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return nil, err
+	}
+	switch inst.Status {
+	case store.StatusRunning, store.StatusStopped:
+	default:
+		return nil, fmt.Errorf("expected status %q or %q, got %q", store.StatusRunning, store.StatusStopped, inst.Status)
+	}
+	return inst, nil
+}
+
+func snapshotInstanceAndTag(cmd *cobra.Command, args []string) (*store.Instance, string, error) {
+	tag, err := cmd.Flags().GetString("tag")
+	if err != nil {
+		return nil, "", err
+	}
+	if tag == "" {
+		return nil, "", errors.New("expected tag")
+	}
+	if err := identifiers.Validate(tag); err != nil {
+		return nil, "", fmt.Errorf("invalid tag %q: %w", tag, err)
+	}
+	inst, err := snapshotInstance(args[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return inst, tag, nil
+}
+
+func qemuConfig(inst *store.Instance) qemu.Config {
+	return qemu.Config{
+		Name:        inst.Name,
+		InstanceDir: inst.Dir,
+	}
+}
+
+func snapshotBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
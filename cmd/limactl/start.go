@@ -14,6 +14,7 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/containerd/containerd/identifiers"
+	hostagentevents "github.com/lima-vm/lima/pkg/hostagent/events"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	networks "github.com/lima-vm/lima/pkg/networks/reconcile"
 	"github.com/lima-vm/lima/pkg/osutil"
@@ -35,6 +36,8 @@ func newStartCommand() *cobra.Command {
 		RunE:              startAction,
 	}
 	startCommand.Flags().Bool("tty", isatty.IsTerminal(os.Stdout.Fd()), "enable TUI interactions such as opening an editor, defaults to true when stdout is a terminal")
+	startCommand.Flags().String("wait", hostagentevents.PhaseReady, fmt.Sprintf("phase to wait for, one of %v", hostagentevents.Phases))
+	startCommand.Flags().Duration("timeout", 0, "duration to wait for the phase (default: `bootTimeout` in lima.yaml + 5m)")
 	return startCommand
 }
 
@@ -141,7 +144,12 @@ func loadOrCreateInstance(cmd *cobra.Command, args []string) (*store.Instance, e
 			answerOpenEditor = false
 		}
 		if answerOpenEditor {
-			yBytes, err = openEditor(cmd, instName, yBytes)
+			hdr := fmt.Sprintf("# Review and modify the following configuration for Lima instance %q.\n", instName)
+			if instName == DefaultInstanceName {
+				hdr += "# - In most cases, you do not need to modify this file.\n"
+			}
+			hdr += "# - To cancel starting Lima, just save this file as an empty file.\n"
+			yBytes, err = openEditor(cmd, instName, yBytes, hdr)
 			if err != nil {
 				return nil, err
 			}
@@ -206,9 +214,10 @@ func askWhetherToOpenEditor(name string) (bool, error) {
 }
 
 // openEditor opens an editor, and returns the content (not path) of the modified yaml.
+// hdr is prepended to the content while editing.
 //
 // openEditor returns nil when the file was saved as an empty file, optionally with whitespaces.
-func openEditor(cmd *cobra.Command, name string, initialContent []byte) ([]byte, error) {
+func openEditor(cmd *cobra.Command, name string, initialContent []byte, hdr string) ([]byte, error) {
 	editor := editorcmd.Detect()
 	if editor == "" {
 		return nil, errors.New("could not detect a text editor binary, try setting $EDITOR")
@@ -219,11 +228,6 @@ func openEditor(cmd *cobra.Command, name string, initialContent []byte) ([]byte,
 	}
 	tmpYAMLPath := tmpYAMLFile.Name()
 	defer os.RemoveAll(tmpYAMLPath)
-	hdr := fmt.Sprintf("# Review and modify the following configuration for Lima instance %q.\n", name)
-	if name == DefaultInstanceName {
-		hdr += "# - In most cases, you do not need to modify this file.\n"
-	}
-	hdr += "# - To cancel starting Lima, just save this file as an empty file.\n"
 	hdr += "\n"
 	if err := os.WriteFile(tmpYAMLPath,
 		append([]byte(hdr), initialContent...),
@@ -253,6 +257,22 @@ func openEditor(cmd *cobra.Command, name string, initialContent []byte) ([]byte,
 }
 
 func startAction(cmd *cobra.Command, args []string) error {
+	waitPhase, err := cmd.Flags().GetString("wait")
+	if err != nil {
+		return err
+	}
+	if hostagentevents.PhaseIndex(waitPhase) < 0 {
+		return fmt.Errorf("unknown phase %q, must be one of %v", waitPhase, hostagentevents.Phases)
+	}
+	startOpts := []start.Opt{start.WithWaitPhase(waitPhase)}
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+	if timeout != 0 {
+		startOpts = append(startOpts, start.WithTimeout(timeout))
+	}
+
 	inst, err := loadOrCreateInstance(cmd, args)
 	if err != nil {
 		return err
@@ -268,6 +288,12 @@ func startAction(cmd *cobra.Command, args []string) error {
 		return nil
 	case store.StatusStopped:
 		// NOP
+	case store.StatusSuspended:
+		if inst.QemuPID > 0 {
+			return fmt.Errorf("instance %q is suspended, run `limactl resume %s` to resume the instance", inst.Name, inst.Name)
+		}
+		// The state saved by `limactl suspend --save` is restored by the hostagent
+		logrus.Infof("Restoring the instance %q from the saved state", inst.Name)
 	default:
 		logrus.Warnf("expected status %q, got %q", store.StatusStopped, inst.Status)
 	}
@@ -276,7 +302,7 @@ func startAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return start.Start(ctx, inst)
+	return start.Start(ctx, inst, startOpts...)
 }
 
 func argSeemsHTTPURL(arg string) bool {
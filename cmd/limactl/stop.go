@@ -48,6 +48,10 @@ func stopAction(cmd *cobra.Command, args []string) error {
 	}
 	if force {
 		stopInstanceForcibly(inst)
+		err = discardSavedState(inst)
+		if err == nil {
+			err = unlockDisks(inst)
+		}
 	} else {
 		err = stopInstanceGracefully(inst)
 	}
@@ -59,8 +63,15 @@ func stopAction(cmd *cobra.Command, args []string) error {
 }
 
 func stopInstanceGracefully(inst *store.Instance) error {
-	if inst.Status != store.StatusRunning {
-		return fmt.Errorf("expected status %q, got %q (maybe use `limactl stop -f`?)", store.StatusRunning, inst.Status)
+	if inst.Status == store.StatusSuspended && inst.QemuPID == 0 {
+		if err := discardSavedState(inst); err != nil {
+			return err
+		}
+		return unlockDisks(inst)
+	}
+	// The hostagent resumes the suspended vCPUs before shutting down the guest
+	if inst.Status != store.StatusRunning && inst.Status != store.StatusSuspended {
+		return fmt.Errorf("expected status %q or %q, got %q (maybe use `limactl stop -f`?)", store.StatusRunning, store.StatusSuspended, inst.Status)
 	}
 
 	begin := time.Now() // used for logrus propagation
@@ -103,6 +114,30 @@ func waitForHostAgentTermination(ctx context.Context, inst *store.Instance, begi
 	return nil
 }
 
+// discardSavedState removes the VM state saved by `limactl suspend --save`.
+// The additional disks held for the saved state have to be released with unlockDisks.
+func discardSavedState(inst *store.Instance) error {
+	vmState := filepath.Join(inst.Dir, filenames.VMState)
+	if _, err := os.Stat(vmState); err == nil {
+		logrus.Infof("Discarding the VM state %q saved by `limactl suspend --save`", vmState)
+		if err := os.RemoveAll(vmState); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(filepath.Join(inst.Dir, filenames.SuspendedMarker))
+}
+
+// unlockDisks releases the additional disks of the stopped instance.
+// The disks are usually unlocked by the hostagent, but may remain locked after a crash of the hostagent,
+// or after `limactl suspend --save`.
+func unlockDisks(inst *store.Instance) error {
+	y, err := inst.LoadYAML()
+	if err != nil {
+		return err
+	}
+	return store.UnlockDisks(inst.Dir, y)
+}
+
 func stopInstanceForcibly(inst *store.Instance) {
 	if inst.QemuPID > 0 {
 		logrus.Infof("Sending SIGKILL to the QEMU process %d", inst.QemuPID)
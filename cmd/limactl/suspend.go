@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	networks "github.com/lima-vm/lima/pkg/networks/reconcile"
+	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/store"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newSuspendCommand() *cobra.Command {
+	var suspendCommand = &cobra.Command{
+		Use:   "suspend INSTANCE",
+		Short: "Suspend an instance",
+		Long: `Suspend an instance, by pausing the vCPUs.
+The instance keeps consuming the memory of the host while suspended.
+
+With --save, the state of the instance is saved to disk, and the QEMU process is terminated so as to release the memory.
+Run "limactl resume" or "limactl start" to restore the state.
+The file sharing needs "limactl stop" and "limactl start" to recover after restoring the state.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              suspendAction,
+		ValidArgsFunction: suspendBashComplete,
+	}
+	suspendCommand.Flags().Bool("save", false, "save the state to disk, and terminate the QEMU process")
+	return suspendCommand
+}
+
+// saveStateTimeout is the timeout for saving the whole memory of the guest to disk
+const saveStateTimeout = 10 * time.Minute
+
+func suspendAction(cmd *cobra.Command, args []string) error {
+	save, err := cmd.Flags().GetBool("save")
+	if err != nil {
+		return err
+	}
+	instName := args[0]
+	inst, err := store.Inspect(instName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("instance %q does not exist, run `limactl start %s` to create a new instance", instName, instName)
+		}
+		return err
+	}
+	switch inst.Status {
+	case store.StatusRunning:
+		logrus.Infof("Suspending the instance %q", instName)
+		if err := qemu.Suspend(qemuConfig(inst)); err != nil {
+			return err
+		}
+	case store.StatusSuspended:
+		if !save || inst.QemuPID == 0 {
+			logrus.Infof("The instance %q is already suspended", instName)
+			return nil
+		}
+	default:
+		return fmt.Errorf("expected status %q, got %q", store.StatusRunning, inst.Status)
+	}
+	if !save {
+		logrus.Infof("Suspended. Run `limactl resume %s` to resume the instance.", instName)
+		return nil
+	}
+
+	logrus.Infof("Saving the state of the instance %q (may take a while)", instName)
+	if err := qemu.SaveState(qemuConfig(inst), saveStateTimeout); err != nil {
+		return err
+	}
+	// The guest must not be shut down, as the disk must match the saved state
+	stopInstanceForcibly(inst)
+	if err := networks.Reconcile(cmd.Context(), ""); err != nil {
+		return err
+	}
+	logrus.Infof("Saved the state. Run `limactl resume %s` to restore the instance.", instName)
+	return nil
+}
+
+func suspendBashComplete(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return bashCompleteInstanceNames(cmd)
+}
@@ -0,0 +1,59 @@
+// Package ansible runs the playbooks of `provision` entries with `mode: ansible` on the host.
+package ansible
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/alessio/shellescape"
+	"github.com/lima-vm/lima/pkg/localpathutil"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"gopkg.in/yaml.v2"
+)
+
+// Inventory returns the Ansible inventory (in YAML) that contains the instance as the host "lima-<INSTANCE>".
+// sshArgs is typically sshutil.SSHArgsFromOpts(sshutil.SSHOpts(...)).
+func Inventory(instName string, sshLocalPort int, sshArgs []string) ([]byte, error) {
+	host := map[string]interface{}{
+		"ansible_connection":      "ssh",
+		"ansible_host":            "127.0.0.1",
+		"ansible_port":            sshLocalPort,
+		"ansible_ssh_common_args": shellescape.QuoteCommand(sshArgs),
+	}
+	inventory := map[string]interface{}{
+		"all": map[string]interface{}{
+			"hosts": map[string]interface{}{
+				"lima-" + instName: host,
+			},
+		},
+	}
+	return yaml.Marshal(inventory)
+}
+
+// WriteInventory writes the inventory to the instance directory, and returns the path.
+func WriteInventory(instDir, instName string, sshLocalPort int, sshArgs []string) (string, error) {
+	b, err := Inventory(instName, sshLocalPort, sshArgs)
+	if err != nil {
+		return "", err
+	}
+	inventoryPath := filepath.Join(instDir, filenames.AnsibleInventoryYAML)
+	if err := os.WriteFile(inventoryPath, b, 0644); err != nil {
+		return "", err
+	}
+	return inventoryPath, nil
+}
+
+// PlaybookCommand returns the ansible-playbook command.
+// The playbook path has to be absolute, or start with "~" (see limayaml.FillDefault).
+func PlaybookCommand(ctx context.Context, inventoryPath, playbook string) (*exec.Cmd, error) {
+	playbook, err := localpathutil.Expand(playbook)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "ansible-playbook", "--inventory", inventoryPath, playbook)
+	// the host key of the instance is not registered in ~/.ssh/known_hosts
+	cmd.Env = append(os.Environ(), "ANSIBLE_HOST_KEY_CHECKING=False")
+	return cmd, nil
+}
@@ -0,0 +1,24 @@
+package ansible
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestInventory(t *testing.T) {
+	b, err := Inventory("default", 60022, []string{"-F", "/dev/null", "-o", "ControlPath=\"/tmp/foo bar/ssh.sock\""})
+	assert.NilError(t, err)
+	var inventory struct {
+		All struct {
+			Hosts map[string]map[string]interface{} `yaml:"hosts"`
+		} `yaml:"all"`
+	}
+	assert.NilError(t, yaml.Unmarshal(b, &inventory))
+	host, ok := inventory.All.Hosts["lima-default"]
+	assert.Assert(t, ok)
+	assert.Equal(t, "127.0.0.1", host["ansible_host"])
+	assert.Equal(t, 60022, host["ansible_port"])
+	assert.Equal(t, `-F /dev/null -o 'ControlPath="/tmp/foo bar/ssh.sock"'`, host["ansible_ssh_common_args"])
+}
@@ -36,33 +36,14 @@ if [ -e /etc/environment ]; then
 fi
 cat "${LIMA_CIDATA_MNT}/etc_environment" >>/etc/environment
 
-if [ -d "${LIMA_CIDATA_MNT}"/provision.system ]; then
-	for f in "${LIMA_CIDATA_MNT}"/provision.system/*; do
-		INFO "Executing $f"
-		if ! "$f"; then
-			WARNING "Failed to execute $f"
-			CODE=1
-		fi
-	done
+if ! "${LIMA_CIDATA_MNT}"/provision.sh; then
+	WARNING "Failed to execute the provisioning scripts"
+	CODE=1
 fi
 
-USER_SCRIPT="/home/${LIMA_CIDATA_USER}.linux/.lima-user-script"
-if [ -d "${LIMA_CIDATA_MNT}"/provision.user ]; then
-	if [ ! -f /sbin/openrc-init ]; then
-		until [ -e "/run/user/${LIMA_CIDATA_UID}/systemd/private" ]; do sleep 3; done
-	fi
-	for f in "${LIMA_CIDATA_MNT}"/provision.user/*; do
-		INFO "Executing $f (as user ${LIMA_CIDATA_USER})"
-		cp "$f" "${USER_SCRIPT}"
-		chown "${LIMA_CIDATA_USER}" "${USER_SCRIPT}"
-		chmod 755 "${USER_SCRIPT}"
-		if ! sudo -iu "${LIMA_CIDATA_USER}" "XDG_RUNTIME_DIR=/run/user/${LIMA_CIDATA_UID}" "${USER_SCRIPT}"; then
-			WARNING "Failed to execute $f (as user ${LIMA_CIDATA_USER})"
-			CODE=1
-		fi
-		rm "${USER_SCRIPT}"
-	done
-fi
+# Notify the hostagent that the boot scripts and the provisioning scripts have finished (even on failures),
+# with the exit code
+echo "$CODE" >/run/lima-boot-done
 
 INFO "Exiting with code $CODE"
 exit "$CODE"
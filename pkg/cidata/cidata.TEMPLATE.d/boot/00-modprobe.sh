@@ -5,6 +5,7 @@
 set -eu
 for f in \
 	fuse \
+	9p 9pnet_virtio \
 	tun tap \
 	bridge veth \
 	ip_tables ip6_tables iptable_nat ip6table_nat iptable_filter ip6table_filter \
@@ -0,0 +1,40 @@
+#!/bin/sh
+
+set -eux
+
+# Format and mount the additional disks (`additionalDisks` in lima.yaml).
+# This script has to run before 05-persistent-data-volume.sh, which formats an unused disk on Alpine.
+test "${LIMA_CIDATA_DISKS:-0}" -gt 0 || exit 0
+
+# NOTE: Busybox sh does not support `for ((i=0;i<$N;i++))` form
+for f in $(seq 0 $((LIMA_CIDATA_DISKS - 1))); do
+	mountpointvar="LIMA_CIDATA_DISKS_${f}_MOUNTPOINT"
+	mountpoint="$(eval echo \$"$mountpointvar")"
+	fstypevar="LIMA_CIDATA_DISKS_${f}_FSTYPE"
+	fstype="$(eval echo \$"$fstypevar")"
+	# The serial is set by the hostagent (see pkg/qemu)
+	device="/dev/disk/by-id/virtio-lima-disk-${f}"
+	if [ ! -e "${device}" ]; then
+		echo >&2 "additional disk ${f} (${device}) not found"
+		continue
+	fi
+	if mountpoint -q "${mountpoint}"; then
+		continue
+	fi
+	# Format the disk only when it does not contain any filesystem, so that the data survives the recreation of the instance.
+	# `blkid -p` exits with 2 only when nothing was found; any other failure must not be mistaken for an empty disk.
+	rc=0
+	blkid -p "${device}" >/dev/null || rc=$?
+	case "${rc}" in
+	0) ;;
+	2)
+		"mkfs.${fstype}" "${device}"
+		;;
+	*)
+		echo >&2 "failed to probe additional disk ${f} (${device}), blkid exited with ${rc}, skipping"
+		continue
+		;;
+	esac
+	mkdir -p "${mountpoint}"
+	mount "${device}" "${mountpoint}"
+done
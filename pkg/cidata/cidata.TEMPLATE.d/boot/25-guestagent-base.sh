@@ -7,6 +7,10 @@ set -eux
 for f in $(seq 0 $((LIMA_CIDATA_MOUNTS - 1))); do
 	mountpointvar="LIMA_CIDATA_MOUNTS_${f}_MOUNTPOINT"
 	mountpoint="$(eval echo \$"$mountpointvar")"
+	if mountpoint -q "${mountpoint}"; then
+		# already mounted, e.g., as a 9p filesystem
+		continue
+	fi
 	mkdir -p "${mountpoint}"
 	gid=$(id -g "${LIMA_CIDATA_USER}")
 	chown "${LIMA_CIDATA_UID}:${gid}" "${mountpoint}"
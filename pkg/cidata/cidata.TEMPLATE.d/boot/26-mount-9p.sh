@@ -0,0 +1,21 @@
+#!/bin/sh
+
+set -eux
+
+test "${LIMA_CIDATA_MOUNTTYPE}" = "9p" || exit 0
+
+# Update fstab entries and unmount/remount the volumes.
+# The entries between #LIMA-START and #LIMA-END are rewritten on every boot.
+sed -i '/#LIMA-START/,/#LIMA-END/d' /etc/fstab
+echo "#LIMA-START" >>/etc/fstab
+# NOTE: Busybox sh does not support `for ((i=0;i<$N;i++))` form
+for f in $(seq 0 $((LIMA_CIDATA_MOUNTS - 1))); do
+	mountpointvar="LIMA_CIDATA_MOUNTS_${f}_MOUNTPOINT"
+	mountpoint="$(eval echo \$"$mountpointvar")"
+	mkdir -p "${mountpoint}"
+	# fstab uses "\040" for spaces
+	escaped="$(echo "${mountpoint}" | sed 's/ /\\040/g')"
+	echo "lima-${f} ${escaped} 9p trans=virtio,version=9p2000.L,msize=131072,cache=mmap,nofail 0 0" >>/etc/fstab
+done
+echo "#LIMA-END" >>/etc/fstab
+mount -a -t 9p
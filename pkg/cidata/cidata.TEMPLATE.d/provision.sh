@@ -0,0 +1,117 @@
+#!/bin/sh
+# Execute the provisioning scripts in "${LIMA_CIDATA_MNT}"/provision, in the order of the indices.
+# The files of `mode: data` are copied to their paths.
+#
+# Usage: provision.sh [--force] [INDEX...]
+#
+# INDEX defaults to all the scripts.
+# The scripts with `when: once` are skipped if they have already succeeded, unless --force is specified.
+# When INDEX is specified and all the specified scripts are skipped, the exit code is 3.
+# Executed by boot.sh on every boot, and by `limactl provision` via SSH.
+set -eu
+
+INFO() {
+	echo "LIMA| $*"
+}
+
+WARNING() {
+	echo "LIMA| WARNING: $*"
+}
+
+LIMA_CIDATA_MNT="${LIMA_CIDATA_MNT:-$(dirname "$0")}"
+
+# shellcheck disable=SC2163
+while read -r line; do export "$line"; done <"${LIMA_CIDATA_MNT}"/lima.env
+
+# shellcheck disable=SC2163
+while read -r line; do
+	[ "$(expr "$line" : '#')" -eq 0 ] && export "$line"
+done <"${LIMA_CIDATA_MNT}"/etc_environment
+
+FORCE=
+if [ "${1:-}" = "--force" ]; then
+	FORCE=1
+	shift
+fi
+EXPLICIT=
+if [ "$#" -gt 0 ]; then
+	EXPLICIT=1
+fi
+if [ "$#" -eq 0 ]; then
+	# shellcheck disable=SC2046
+	set -- $(seq 0 $((LIMA_CIDATA_PROVISION - 1)))
+fi
+
+# The checksums of the names, the modes, and the contents of the `when: once` scripts that have succeeded
+STATE_DIR=/var/lib/lima-provision
+USER_SCRIPT="/home/${LIMA_CIDATA_USER}.linux/.lima-user-script"
+CODE=0
+SKIPPED=0
+for i in "$@"; do
+	f="${LIMA_CIDATA_MNT}/provision/$(printf "%08d" "$i")"
+	name="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_NAME")"
+	mode="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_MODE")"
+	when="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_WHEN")"
+	if [ ! -f "$f" ]; then
+		WARNING "Provision ${i} does not exist"
+		CODE=1
+		continue
+	fi
+	# The name and the mode are included, so that the scripts with the same content do not share the state
+	state="${STATE_DIR}/$({ printf '%s\n%s\n' "$name" "$mode" && cat "$f"; } | sha256sum | cut -d' ' -f1)"
+	if [ "$when" = "once" ] && [ -z "$FORCE" ] && [ -e "$state" ]; then
+		INFO "Skipping ${name} (already executed once)"
+		SKIPPED=$((SKIPPED + 1))
+		continue
+	fi
+	case "$mode" in
+	system)
+		INFO "Executing ${name}"
+		if ! "$f"; then
+			WARNING "Failed to execute ${name}"
+			CODE=1
+			continue
+		fi
+		;;
+	user)
+		if [ ! -f /sbin/openrc-init ]; then
+			until [ -e "/run/user/${LIMA_CIDATA_UID}/systemd/private" ]; do sleep 3; done
+		fi
+		INFO "Executing ${name} (as user ${LIMA_CIDATA_USER})"
+		cp "$f" "${USER_SCRIPT}"
+		chown "${LIMA_CIDATA_USER}" "${USER_SCRIPT}"
+		chmod 755 "${USER_SCRIPT}"
+		if ! sudo -iu "${LIMA_CIDATA_USER}" "XDG_RUNTIME_DIR=/run/user/${LIMA_CIDATA_UID}" "${USER_SCRIPT}"; then
+			WARNING "Failed to execute ${name} (as user ${LIMA_CIDATA_USER})"
+			rm "${USER_SCRIPT}"
+			CODE=1
+			continue
+		fi
+		rm "${USER_SCRIPT}"
+		;;
+	data)
+		path="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_PATH")"
+		owner="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_OWNER")"
+		permissions="$(eval echo \$"LIMA_CIDATA_PROVISION_${i}_PERMISSIONS")"
+		INFO "Writing ${name} to ${path}"
+		if ! { mkdir -p "$(dirname "${path}")" && cp "$f" "${path}" && chown "${owner}" "${path}" && chmod "${permissions}" "${path}"; }; then
+			WARNING "Failed to write ${name} to ${path}"
+			CODE=1
+			continue
+		fi
+		;;
+	*)
+		WARNING "Unknown mode ${mode} of ${name}"
+		CODE=1
+		continue
+		;;
+	esac
+	if [ "$when" = "once" ]; then
+		mkdir -p "${STATE_DIR}"
+		touch "$state"
+	fi
+done
+if [ "$CODE" -eq 0 ] && [ -n "$EXPLICIT" ] && [ "$SKIPPED" -eq "$#" ]; then
+	exit 3
+fi
+exit "$CODE"
@@ -1,6 +1,7 @@
 package cidata
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lima-vm/lima/pkg/downloader"
 	"github.com/lima-vm/lima/pkg/iso9660util"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	"github.com/lima-vm/lima/pkg/localpathutil"
@@ -120,11 +122,11 @@ func GenerateISO9660(instDir, name string, y *limayaml.LimaYAML, udpDNSLocalPort
 	}
 
 	for _, f := range y.Mounts {
-		expanded, err := localpathutil.Expand(f.Location)
-		if err != nil {
-			return err
-		}
-		args.Mounts = append(args.Mounts, expanded)
+		args.Mounts = append(args.Mounts, f.MountPoint)
+	}
+	args.MountType = y.MountType
+	for _, d := range y.AdditionalDisks {
+		args.Disks = append(args.Disks, Disk{MountPoint: d.MountPoint, FSType: d.FSType})
 	}
 
 	slirpMACAddress := limayaml.MACAddress(instDir)
@@ -152,27 +154,49 @@ func GenerateISO9660(instDir, name string, y *limayaml.LimaYAML, udpDNSLocalPort
 		}
 	}
 
-	if err := ValidateTemplateArgs(args); err != nil {
-		return err
-	}
-
-	layout, err := ExecuteTemplate(args)
+	provisionOrder, err := limayaml.ProvisionOrder(y.Provision)
 	if err != nil {
 		return err
 	}
-
-	for i, f := range y.Provision {
+	var provisionLayout []iso9660util.Entry
+	for _, i := range provisionOrder {
+		f := y.Provision[i]
 		switch f.Mode {
-		case limayaml.ProvisionModeSystem, limayaml.ProvisionModeUser:
-			layout = append(layout, iso9660util.Entry{
-				Path:   fmt.Sprintf("provision.%s/%08d", f.Mode, i),
-				Reader: strings.NewReader(f.Script),
+		case limayaml.ProvisionModeAnsible:
+			// executed on the host by the hostagent
+			continue
+		case limayaml.ProvisionModeSystem, limayaml.ProvisionModeUser, limayaml.ProvisionModeData:
+			content, err := provisionContent(f)
+			if err != nil {
+				return err
+			}
+			provisionLayout = append(provisionLayout, iso9660util.Entry{
+				Path:   fmt.Sprintf("provision/%08d", len(args.Provision)),
+				Reader: bytes.NewReader(content),
+			})
+			args.Provision = append(args.Provision, Provision{
+				Name:        f.Name,
+				Mode:        f.Mode,
+				When:        f.When,
+				Path:        f.Path,
+				Owner:       f.Owner,
+				Permissions: f.Permissions,
 			})
 		default:
 			return fmt.Errorf("unknown provision mode %q", f.Mode)
 		}
 	}
 
+	if err := ValidateTemplateArgs(args); err != nil {
+		return err
+	}
+
+	layout, err := ExecuteTemplate(args)
+	if err != nil {
+		return err
+	}
+	layout = append(layout, provisionLayout...)
+
 	if guestAgentBinary, err := GuestAgentBinary(y.Arch); err != nil {
 		return err
 	} else {
@@ -199,6 +223,47 @@ func GenerateISO9660(instDir, name string, y *limayaml.LimaYAML, udpDNSLocalPort
 	return iso9660util.Write(filepath.Join(instDir, filenames.CIDataISO), "cidata", layout)
 }
 
+// provisionContent returns the script, or the content for "data".
+// When `file` is specified, the file is fetched with the downloader, using the cache for URLs.
+func provisionContent(f limayaml.Provision) ([]byte, error) {
+	if f.File == nil {
+		if f.Mode == limayaml.ProvisionModeData {
+			return []byte(f.Content), nil
+		}
+		return []byte(f.Script), nil
+	}
+	location := f.File.Location
+	if !strings.Contains(location, "://") {
+		var err error
+		location, err = localpathutil.Expand(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.File.Digest == "" {
+		// The file may change without the digest, so it is downloaded every time instead of being cached
+		tmpDir, err := os.MkdirTemp("", "lima-provision-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		local := filepath.Join(tmpDir, "data")
+		if _, err := downloader.Download(local, location); err != nil {
+			return nil, fmt.Errorf("failed to download %q for provision %q: %w", f.File.Location, f.Name, err)
+		}
+		return os.ReadFile(local)
+	}
+	res, err := downloader.Download("", location, downloader.WithCache(), downloader.WithExpectedDigest(f.File.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %q for provision %q: %w", f.File.Location, f.Name, err)
+	}
+	if res.CachePath != "" {
+		return os.ReadFile(res.CachePath)
+	}
+	// local files are not cached
+	return os.ReadFile(strings.TrimPrefix(location, "file://"))
+}
+
 func GuestAgentBinary(arch string) (io.ReadCloser, error) {
 	if arch == "" {
 		return nil, errors.New("arch must be set")
@@ -0,0 +1,38 @@
+package cidata
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestProvisionContentWithoutDigest(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("HOME", cacheHome)
+	var count int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		fmt.Fprintf(w, "#!/bin/sh\necho %d\n", count)
+	}))
+	defer ts.Close()
+
+	f := limayaml.Provision{Mode: limayaml.ProvisionModeSystem, File: &limayaml.File{Location: ts.URL + "/provision.sh"}}
+	// not cached, as the file may change without the digest
+	b, err := provisionContent(f)
+	assert.NilError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho 1\n", string(b))
+	b, err = provisionContent(f)
+	assert.NilError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho 2\n", string(b))
+
+	// the cache directory stays empty
+	entries, err := os.ReadDir(cacheHome)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
@@ -27,6 +27,18 @@ type Network struct {
 	MACAddress string
 	Interface  string
 }
+type Disk struct {
+	MountPoint string
+	FSType     string
+}
+type Provision struct {
+	Name        string
+	Mode        string
+	When        string
+	Path        string // only for "data"
+	Owner       string // only for "data"
+	Permissions string // only for "data"
+}
 type TemplateArgs struct {
 	Name            string // instance name
 	IID             string // instance id
@@ -34,6 +46,8 @@ type TemplateArgs struct {
 	UID             int
 	SSHPubKeys      []string
 	Mounts          []string // abs path, accessible by the User
+	MountType       string
+	Disks           []Disk // additional disks, attached as /dev/disk/by-id/virtio-lima-disk-N
 	Containerd      Containerd
 	Networks        []Network
 	SlirpNICName    string
@@ -43,6 +57,7 @@ type TemplateArgs struct {
 	TCPDNSLocalPort int
 	Env             map[string]string
 	DNSAddresses    []string
+	Provision       []Provision // in the order of execution
 }
 
 func ValidateTemplateArgs(args TemplateArgs) error {
@@ -66,6 +81,11 @@ func ValidateTemplateArgs(args TemplateArgs) error {
 			return fmt.Errorf("field mounts[%d] must be absolute, got %q", i, f)
 		}
 	}
+	for i, d := range args.Disks {
+		if !filepath.IsAbs(d.MountPoint) {
+			return fmt.Errorf("field disks[%d].MountPoint must be absolute, got %q", i, d.MountPoint)
+		}
+	}
 	return nil
 }
 
@@ -19,6 +19,9 @@ func TestTemplate(t *testing.T) {
 			"/Users/dummy",
 			"/Users/dummy/lima",
 		},
+		Disks: []Disk{
+			{MountPoint: "/mnt/lima-data", FSType: "ext4"},
+		},
 	}
 	layout, err := ExecuteTemplate(args)
 	assert.NilError(t, err)
@@ -10,9 +10,17 @@ var (
 	IPv4loopback1 = net.IPv4(127, 0, 0, 1)
 )
 
+type Proto = string
+
+const (
+	TCP Proto = "tcp"
+	UDP Proto = "udp"
+)
+
 type IPPort struct {
-	IP   net.IP `json:"ip"`
-	Port int    `json:"port"`
+	IP       net.IP `json:"ip"`
+	Port     int    `json:"port"`
+	Protocol Proto  `json:"protocol"`
 }
 
 func (x *IPPort) String() string {
@@ -20,10 +28,13 @@ func (x *IPPort) String() string {
 }
 
 type Info struct {
-	// LocalPorts contain 127.0.0.1 and 0.0.0.0.
+	// LocalPorts contain 127.0.0.1, 0.0.0.0, ::1, and ::.
+	// LocalPorts contain both TCP listeners and UDP sockets that seem to be servers
+	// (unconnected, and bound to the addresses above).
 	// LocalPorts do NOT contain addresses such as 127.0.0.53 and 192.168.5.15.
 	//
-	// In future, LocalPorts will contain IPv6 addresses (::1 and ::) as well.
+	// A socket bound to :: is listed once with the IPv6 address, even when it
+	// accepts IPv4 connections too.
 	LocalPorts []IPPort `json:"localPorts"`
 }
 
@@ -6,17 +6,22 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/lima-vm/lima/pkg/guestagent/api"
 	"github.com/lima-vm/lima/pkg/httpclientutil"
+	"github.com/lima-vm/lima/pkg/udptunnel"
 )
 
 type GuestAgentClient interface {
 	HTTPClient() *http.Client
 	Info(context.Context) (*api.Info, error)
 	Events(context.Context, func(api.Event)) error
+	DialUDP(ctx context.Context, remote string) (io.ReadWriteCloser, error)
 }
 
 // NewGuestAgentClient creates a client.
@@ -80,3 +85,32 @@ func (c *client) Events(ctx context.Context, onEvent func(api.Event)) error {
 		onEvent(ev)
 	}
 }
+
+// DialUDP opens a tunnel to the UDP address remote in the guest.
+// The returned stream carries datagrams encoded with pkg/udptunnel.
+func (c *client) DialUDP(ctx context.Context, remote string) (io.ReadWriteCloser, error) {
+	u := fmt.Sprintf("http://%s/%s/udp?addr=%s", c.dummyHost, c.version, url.QueryEscape(remote))
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", udptunnel.Upgrade)
+	resp, err := c.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		if err := httpclientutil.Successful(resp); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("expected HTTP status %q, got %q", http.StatusText(http.StatusSwitchingProtocols), resp.Status)
+	}
+	stream, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("the upgraded connection is not writable")
+	}
+	return stream, nil
+}
@@ -3,12 +3,17 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/lima-vm/lima/pkg/guestagent"
 	"github.com/lima-vm/lima/pkg/guestagent/api"
 	"github.com/lima-vm/lima/pkg/httputil"
+	"github.com/lima-vm/lima/pkg/udptunnel"
 	"github.com/sirupsen/logrus"
 )
 
@@ -76,8 +81,80 @@ func (b *Backend) GetEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetUDP is the handler for GET /v{N}/udp?addr=IP:PORT.
+//
+// The connection is upgraded to a tunnel of UDP datagrams for addr (see pkg/udptunnel).
+func (b *Backend) GetUDP(w http.ResponseWriter, r *http.Request) {
+	addr := r.URL.Query().Get("addr")
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		b.onError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if err := b.checkUDPAddr(r.Context(), addr); err != nil {
+		b.onError(w, r, err, http.StatusForbidden)
+		return
+	}
+	if upgrade := r.Header.Get("Upgrade"); upgrade != udptunnel.Upgrade {
+		b.onError(w, r, fmt.Errorf("expected header \"Upgrade: %s\", got %q", udptunnel.Upgrade, upgrade), http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic("http.ResponseWriter has to implement http.Hijacker")
+	}
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(bufrw, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: %s\r\n\r\n", udptunnel.Upgrade)
+	if err := bufrw.Flush(); err != nil {
+		logrus.Warn(err)
+		conn.Close()
+		return
+	}
+	stream := struct {
+		io.Reader
+		io.Writer
+		io.Closer
+	}{bufrw.Reader, conn, conn}
+	logrus.Debugf("relaying UDP datagrams to %q", addr)
+	if err := udptunnel.Serve(stream, addr); err != nil {
+		logrus.WithError(err).Debugf("stopped relaying UDP datagrams to %q", addr)
+	}
+}
+
+// checkUDPAddr checks that addr is a loopback or wildcard address reported by Agent.LocalPorts,
+// so that the tunnel cannot be used for sending datagrams to arbitrary hosts.
+func (b *Backend) checkUDPAddr(ctx context.Context, addr string) error {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !(ip.IsLoopback() || ip.IsUnspecified()) {
+		return fmt.Errorf("expected a loopback or wildcard address, got %q", addr)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return err
+	}
+	localPorts, err := b.Agent.LocalPorts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range localPorts {
+		if f.Protocol == api.UDP && f.Port == port && f.IP.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("UDP address %q is not a local port of the guest", addr)
+}
+
 func AddRoutes(r *mux.Router, b *Backend) {
 	v1 := r.PathPrefix("/v1").Subrouter()
 	v1.Path("/info").Methods("GET").HandlerFunc(b.GetInfo)
 	v1.Path("/events").Methods("GET").HandlerFunc(b.GetEvents)
+	v1.Path("/udp").Methods("GET").HandlerFunc(b.GetUDP)
 }
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/guestagent/api"
+	"gotest.tools/v3/assert"
+)
+
+type fakeAgent struct {
+	localPorts []api.IPPort
+}
+
+func (a *fakeAgent) Info(ctx context.Context) (*api.Info, error) {
+	return &api.Info{LocalPorts: a.localPorts}, nil
+}
+
+func (a *fakeAgent) Events(ctx context.Context, ch chan api.Event) {
+	close(ch)
+}
+
+func (a *fakeAgent) LocalPorts(ctx context.Context) ([]api.IPPort, error) {
+	return a.localPorts, nil
+}
+
+func TestCheckUDPAddr(t *testing.T) {
+	b := &Backend{
+		Agent: &fakeAgent{
+			localPorts: []api.IPPort{
+				{IP: net.IPv4zero, Port: 53, Protocol: api.UDP},
+				{IP: net.ParseIP("127.0.0.1"), Port: 8125, Protocol: api.UDP},
+				{IP: net.IPv4zero, Port: 80, Protocol: api.TCP},
+			},
+		},
+	}
+	ctx := context.Background()
+	assert.NilError(t, b.checkUDPAddr(ctx, "0.0.0.0:53"))
+	assert.NilError(t, b.checkUDPAddr(ctx, "127.0.0.1:8125"))
+	assert.ErrorContains(t, b.checkUDPAddr(ctx, "192.168.5.3:53"), "expected a loopback or wildcard address")
+	assert.ErrorContains(t, b.checkUDPAddr(ctx, "example.com:53"), "expected a loopback or wildcard address")
+	assert.ErrorContains(t, b.checkUDPAddr(ctx, "0.0.0.0:80"), "not a local port")
+	assert.ErrorContains(t, b.checkUDPAddr(ctx, "127.0.0.1:53"), "not a local port")
+}
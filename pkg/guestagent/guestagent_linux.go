@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"net"
 	"reflect"
 	"sync"
 	"time"
@@ -98,12 +99,12 @@ func comparePorts(old, neww []api.IPPort) (added, removed []api.IPPort) {
 	mStillExist := make(map[string]bool, len(old))
 
 	for _, f := range old {
-		k := f.String()
+		k := f.Protocol + ":" + f.String()
 		mRaw[k] = f
 		mStillExist[k] = false
 	}
 	for _, f := range neww {
-		k := f.String()
+		k := f.Protocol + ":" + f.String()
 		if _, ok := mRaw[k]; !ok {
 			added = append(added, f)
 		}
@@ -181,15 +182,23 @@ func (a *agent) LocalPorts(ctx context.Context) ([]api.IPPort, error) {
 	for _, f := range tcpParsed {
 		switch f.Kind {
 		case procnettcp.TCP, procnettcp.TCP6:
-		default:
-			continue
-		}
-		if f.State == procnettcp.TCPListen {
-			res = append(res,
-				api.IPPort{
-					IP:   f.IP,
-					Port: int(f.Port),
-				})
+			if f.State == procnettcp.TCPListen {
+				res = append(res,
+					api.IPPort{
+						IP:       f.IP,
+						Port:     int(f.Port),
+						Protocol: api.TCP,
+					})
+			}
+		case procnettcp.UDP, procnettcp.UDP6:
+			if isUDPListener(f) {
+				res = append(res,
+					api.IPPort{
+						IP:       f.IP,
+						Port:     int(f.Port),
+						Protocol: api.UDP,
+					})
+			}
 		}
 	}
 
@@ -214,18 +223,23 @@ func (a *agent) LocalPorts(ctx context.Context) ([]api.IPPort, error) {
 	}
 
 	for _, ipt := range ipts {
+		protocol := api.UDP
+		if ipt.TCP {
+			protocol = api.TCP
+		}
 		// Make sure the port isn't already listed from procnettcp
 		found := false
 		for _, re := range res {
-			if re.Port == ipt.Port {
+			if re.Port == ipt.Port && re.Protocol == protocol {
 				found = true
 			}
 		}
 		if !found {
 			res = append(res,
 				api.IPPort{
-					IP:   ipt.IP,
-					Port: ipt.Port,
+					IP:       ipt.IP,
+					Port:     ipt.Port,
+					Protocol: protocol,
 				})
 		}
 	}
@@ -233,6 +247,26 @@ func (a *agent) LocalPorts(ctx context.Context) ([]api.IPPort, error) {
 	return res, nil
 }
 
+// isUDPListener returns whether the UDP socket seems to be a server socket.
+// A UDP socket has no listening state, so a socket is considered to be a server socket only when
+// it is bound to a wildcard or loopback address (127.0.0.1 or ::1) without a remote address.
+// Client sockets, such as the DHCP client and the ephemeral ports of resolvers, are excluded.
+func isUDPListener(f procnettcp.Entry) bool {
+	if f.State != procnettcp.UDPUnconnected {
+		return false
+	}
+	if f.RemotePort != 0 || (f.RemoteIP != nil && !f.RemoteIP.IsUnspecified()) {
+		return false
+	}
+	switch f.Port {
+	case 68, 546:
+		// DHCP and DHCPv6 clients may listen on the wildcard address
+		return false
+	}
+	// 127.0.0.53 (systemd-resolved) is excluded, as documented in api.Info
+	return f.IP.IsUnspecified() || f.IP.Equal(api.IPv4loopback1) || f.IP.Equal(net.IPv6loopback)
+}
+
 func (a *agent) Info(ctx context.Context) (*api.Info, error) {
 	var (
 		info api.Info
@@ -0,0 +1,57 @@
+package guestagent
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/guestagent/procnettcp"
+	"gotest.tools/v3/assert"
+)
+
+func TestIsUDPListener(t *testing.T) {
+	type testCase struct {
+		entry    procnettcp.Entry
+		expected bool
+	}
+	testCases := []testCase{
+		{
+			entry:    procnettcp.Entry{IP: net.IPv4zero, Port: 53, RemoteIP: net.IPv4zero, State: procnettcp.UDPUnconnected},
+			expected: true,
+		},
+		{
+			entry:    procnettcp.Entry{IP: net.ParseIP("127.0.0.1"), Port: 8125, RemoteIP: net.IPv4zero, State: procnettcp.UDPUnconnected},
+			expected: true,
+		},
+		{
+			entry:    procnettcp.Entry{IP: net.IPv6zero, Port: 5353, RemoteIP: net.IPv6zero, State: procnettcp.UDPUnconnected},
+			expected: true,
+		},
+		{
+			// systemd-resolved
+			entry:    procnettcp.Entry{IP: net.ParseIP("127.0.0.53"), Port: 53, RemoteIP: net.IPv4zero, State: procnettcp.UDPUnconnected},
+			expected: false,
+		},
+		{
+			// DHCP client
+			entry:    procnettcp.Entry{IP: net.IPv4zero, Port: 68, RemoteIP: net.IPv4zero, State: procnettcp.UDPUnconnected},
+			expected: false,
+		},
+		{
+			// bound to a specific address
+			entry:    procnettcp.Entry{IP: net.ParseIP("192.168.5.15"), Port: 40000, RemoteIP: net.IPv4zero, State: procnettcp.UDPUnconnected},
+			expected: false,
+		},
+		{
+			// connected client
+			entry:    procnettcp.Entry{IP: net.IPv4zero, Port: 40000, RemoteIP: net.ParseIP("192.168.5.3"), RemotePort: 53, State: procnettcp.UDPUnconnected},
+			expected: false,
+		},
+		{
+			entry:    procnettcp.Entry{IP: net.IPv4zero, Port: 40000, RemoteIP: net.ParseIP("192.168.5.3"), RemotePort: 53, State: procnettcp.TCPEstablished},
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isUDPListener(tc.entry), "%+v", tc.entry)
+	}
+}
@@ -31,14 +31,35 @@ type Entry struct {
 // -j DNAT this tells us it's the line doing the port forwarding.
 var findPortRegex = regexp.MustCompile(`-A\s+CNI-DN-\w*\s+(?:-d ((?:\b25[0-5]|\b2[0-4][0-9]|\b[01]?[0-9][0-9]?)(?:\.(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)){3}))?(?:/32\s+)?-p (tcp)?.*--dport (\d+) -j DNAT`)
 
+// findPortRegex6 is the equivalent of findPortRegex for the rules added by
+// portmap to ip6tables, e.g.,
+//    -A CNI-DN-2e2f8d5b91929ef9fc152 -d ::1/128 -p tcp -m tcp --dport 8081 -j DNAT --to-destination [fd00::7]:80
+// The -d address is not validated strictly; net.ParseIP rejects invalid addresses.
+var findPortRegex6 = regexp.MustCompile(`-A\s+CNI-DN-\w*\s+(?:-d ([0-9a-fA-F:.]+))?(?:/128\s+)?-p (tcp)?.*--dport (\d+) -j DNAT`)
+
+// GetPorts returns the ports forwarded by iptables and ip6tables.
 func GetPorts() ([]Entry, error) {
-	// TODO: add support for ipv6
+	var pts []Entry
+	for _, ipv6 := range []bool{false, true} {
+		x, err := getPorts(ipv6)
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, x...)
+	}
+	return checkPortsOpen(pts)
+}
 
+func getPorts(ipv6 bool) ([]Entry, error) {
+	bin := "iptables"
+	if ipv6 {
+		bin = "ip6tables"
+	}
 	// Detect the location of iptables. If it is not installed skip the lookup
 	// and return no results. The lookup is performed on each run so that the
 	// agent does not need to be started to detect if iptables was installed
 	// after the agent is already running.
-	pth, err := exec.LookPath("iptables")
+	pth, err := exec.LookPath(bin)
 	if err != nil {
 		if errors.Is(err, exec.ErrNotFound) {
 			return nil, nil
@@ -52,18 +73,17 @@ func GetPorts() ([]Entry, error) {
 		return nil, err
 	}
 
-	pts, err := parsePortsFromRules(res)
-	if err != nil {
-		return nil, err
-	}
-
-	return checkPortsOpen(pts)
+	return parsePortsFromRules(res, ipv6)
 }
 
-func parsePortsFromRules(rules []string) ([]Entry, error) {
+func parsePortsFromRules(rules []string, ipv6 bool) ([]Entry, error) {
+	re, loopback := findPortRegex, "127.0.0.1"
+	if ipv6 {
+		re, loopback = findPortRegex6, "::1"
+	}
 	var entries []Entry
 	for _, rule := range rules {
-		if found := findPortRegex.FindStringSubmatch(rule); found != nil {
+		if found := re.FindStringSubmatch(rule); found != nil {
 			if len(found) == 4 {
 				port, err := strconv.Atoi(found[3])
 				if err != nil {
@@ -80,10 +100,14 @@ func parsePortsFromRules(rules []string) ([]Entry, error) {
 				// is present localhost will work.
 				ip := found[1]
 				if ip == "" {
-					ip = "127.0.0.1"
+					ip = loopback
+				}
+				parsedIP := net.ParseIP(ip)
+				if parsedIP == nil {
+					continue
 				}
 				ent := Entry{
-					IP:   net.ParseIP(ip),
+					IP:   parsedIP,
 					Port: port,
 					TCP:  istcp,
 				}
@@ -74,7 +74,7 @@ func TestParsePortsFromRules(t *testing.T) {
 		rules = rules[:len(rules)-1]
 	}
 
-	res, err := parsePortsFromRules(rules)
+	res, err := parsePortsFromRules(rules, false)
 	if err != nil {
 		t.Errorf("parsing iptables ports failed with error: %s", err)
 	}
@@ -91,3 +91,48 @@ func TestParsePortsFromRules(t *testing.T) {
 		t.Errorf("expected port 8081 on IP 127.0.0.1 with TCP true but go port %d on IP %s with TCP %t", res[1].Port, res[1].IP.String(), res[1].TCP)
 	}
 }
+
+// data6 is from a run of `ip6tables -t nat -S` with two containers running (started
+// with sudo nerdctl) and have exposed ports 8081 and 8082.
+const data6 = `-P PREROUTING ACCEPT
+-P INPUT ACCEPT
+-P OUTPUT ACCEPT
+-P POSTROUTING ACCEPT
+-N CNI-DN-04579c7bb67f4c3f6cca0
+-N CNI-DN-2e2f8d5b91929ef9fc152
+-N CNI-HOSTPORT-DNAT
+-N CNI-HOSTPORT-SETMARK
+-A PREROUTING -m addrtype --dst-type LOCAL -j CNI-HOSTPORT-DNAT
+-A OUTPUT -m addrtype --dst-type LOCAL -j CNI-HOSTPORT-DNAT
+-A CNI-DN-04579c7bb67f4c3f6cca0 -s fd00:4::/64 -p tcp -m tcp --dport 8082 -j CNI-HOSTPORT-SETMARK
+-A CNI-DN-04579c7bb67f4c3f6cca0 -s ::1/128 -p tcp -m tcp --dport 8082 -j CNI-HOSTPORT-SETMARK
+-A CNI-DN-04579c7bb67f4c3f6cca0 -p tcp -m tcp --dport 8082 -j DNAT --to-destination [fd00:4::a]:80
+-A CNI-DN-2e2f8d5b91929ef9fc152 -s fd00:4::/64 -d fd00:5::1/128 -p tcp -m tcp --dport 8081 -j CNI-HOSTPORT-SETMARK
+-A CNI-DN-2e2f8d5b91929ef9fc152 -d fd00:5::1/128 -p tcp -m tcp --dport 8081 -j DNAT --to-destination [fd00:4::7]:80
+-A CNI-HOSTPORT-DNAT -p tcp -m comment --comment "dnat name: \"bridge\" id: \"default-c93e2a3a2264f98647f0d33dc80d88de81c0710bf30ea822e2ed19213f9c53b5\"" -m multiport --dports 8081 -j CNI-DN-2e2f8d5b91929ef9fc152
+-A CNI-HOSTPORT-SETMARK -m comment --comment "CNI portfwd masquerade mark" -j MARK --set-xmark 0x2000/0x2000
+`
+
+func TestParsePortsFromRules6(t *testing.T) {
+	rules := strings.Split(data6, "\n")
+	if len(rules) > 0 && rules[len(rules)-1] == "" {
+		rules = rules[:len(rules)-1]
+	}
+
+	res, err := parsePortsFromRules(rules, true)
+	if err != nil {
+		t.Errorf("parsing ip6tables ports failed with error: %s", err)
+	}
+
+	l := len(res)
+	if l != 2 {
+		t.Fatalf("expected 2 ports parsed from ip6tables but parsed %d", l)
+	}
+
+	if res[0].IP.String() != "::1" || res[0].Port != 8082 || res[0].TCP != true {
+		t.Errorf("expected port 8082 on IP ::1 with TCP true but go port %d on IP %s with TCP %t", res[0].Port, res[0].IP.String(), res[0].TCP)
+	}
+	if res[1].IP.String() != "fd00:5::1" || res[1].Port != 8081 || res[1].TCP != true {
+		t.Errorf("expected port 8081 on IP fd00:5::1 with TCP true but go port %d on IP %s with TCP %t", res[1].Port, res[1].IP.String(), res[1].TCP)
+	}
+}
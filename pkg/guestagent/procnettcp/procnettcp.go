@@ -15,26 +15,31 @@ type Kind = string
 const (
 	TCP  Kind = "tcp"
 	TCP6 Kind = "tcp6"
-	// TODO: "udp", "udp6", "udplite", "udplite6"
+	UDP  Kind = "udp"
+	UDP6 Kind = "udp6"
+	// TODO: "udplite", "udplite6"
 )
 
 type State = int
 
 const (
 	TCPEstablished State = 0x1
+	UDPUnconnected State = 0x7
 	TCPListen      State = 0xA
 )
 
 type Entry struct {
-	Kind  Kind   `json:"kind"`
-	IP    net.IP `json:"ip"`
-	Port  uint16 `json:"port"`
-	State State  `json:"state"`
+	Kind       Kind   `json:"kind"`
+	IP         net.IP `json:"ip"`
+	Port       uint16 `json:"port"`
+	RemoteIP   net.IP `json:"remoteIP,omitempty"`
+	RemotePort uint16 `json:"remotePort,omitempty"`
+	State      State  `json:"state"`
 }
 
 func Parse(r io.Reader, kind Kind) ([]Entry, error) {
 	switch kind {
-	case TCP, TCP6:
+	case TCP, TCP6, UDP, UDP6:
 	default:
 		return nil, fmt.Errorf("unexpected kind %q", kind)
 	}
@@ -61,6 +66,10 @@ func Parse(r io.Reader, kind Kind) ([]Entry, error) {
 			if _, ok := fieldNames["st"]; !ok {
 				return nil, fmt.Errorf("field \"st\" not found")
 			}
+			// "rem_address" for tcp and udp, "remote_address" for tcp6 and udp6
+			if j, ok := fieldNames["remote_address"]; ok {
+				fieldNames["rem_address"] = j
+			}
 
 		default:
 			// localAddress is like "0100007F:053A"
@@ -82,6 +91,12 @@ func Parse(r io.Reader, kind Kind) ([]Entry, error) {
 				Port:  port,
 				State: int(st),
 			}
+			if j, ok := fieldNames["rem_address"]; ok && j < len(fields) {
+				ent.RemoteIP, ent.RemotePort, err = ParseAddress(fields[j])
+				if err != nil {
+					return entries, err
+				}
+			}
 			entries = append(entries, ent)
 		}
 	}
@@ -99,7 +114,7 @@ func Parse(r io.Reader, kind Kind) ([]Entry, error) {
 //
 // See https://serverfault.com/questions/592574/why-does-proc-net-tcp6-represents-1-as-1000
 //
-// ParseAddress is expected to be used for /proc/net/{tcp,tcp6} entries on
+// ParseAddress is expected to be used for /proc/net/{tcp,tcp6,udp,udp6} entries on
 // little endian machines.
 // Not sure how those entries look like on big endian machines.
 func ParseAddress(s string) (net.IP, uint16, error) {
@@ -56,3 +56,26 @@ func TestParseTCP6Zero(t *testing.T) {
 	assert.Equal(t, uint16(22), entries[0].Port)
 	assert.Equal(t, TCPListen, entries[0].State)
 }
+
+func TestParseUDP(t *testing.T) {
+	procNetUDP := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  765: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 17893 2 0000000000000000 0
+  780: 0F05A8C0:0044 0205A8C0:0043 01 00000000:00000000 00:00000000 00000000   100        0 29112 2 0000000000000000 0
+`
+	entries, err := Parse(strings.NewReader(procNetUDP), UDP)
+	assert.NilError(t, err)
+	t.Log(entries)
+
+	assert.Check(t, net.ParseIP("127.0.0.53").Equal(entries[0].IP))
+	assert.Equal(t, uint16(53), entries[0].Port)
+	assert.Equal(t, UDPUnconnected, entries[0].State)
+
+	assert.Check(t, net.IPv4zero.Equal(entries[0].RemoteIP))
+	assert.Equal(t, uint16(0), entries[0].RemotePort)
+
+	assert.Check(t, net.ParseIP("192.168.5.15").Equal(entries[1].IP))
+	assert.Equal(t, uint16(68), entries[1].Port)
+	assert.Equal(t, TCPEstablished, entries[1].State)
+	assert.Check(t, net.ParseIP("192.168.5.2").Equal(entries[1].RemoteIP))
+	assert.Equal(t, uint16(67), entries[1].RemotePort)
+}
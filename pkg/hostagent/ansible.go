@@ -0,0 +1,57 @@
+package hostagent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/lima-vm/lima/pkg/ansible"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/sirupsen/logrus"
+)
+
+// runAnsiblePlaybooks runs the playbooks of the provisions with `mode: ansible` on the host, in the order of execution.
+func (a *HostAgent) runAnsiblePlaybooks(ctx context.Context) error {
+	order, err := limayaml.ProvisionOrder(a.y.Provision)
+	if err != nil {
+		return err
+	}
+	var (
+		inventoryPath string
+		mErr          error
+	)
+	for _, i := range order {
+		p := a.y.Provision[i]
+		if p.Mode != limayaml.ProvisionModeAnsible {
+			continue
+		}
+		if inventoryPath == "" {
+			inventoryPath, err = ansible.WriteInventory(a.instDir, filepath.Base(a.instDir), a.sshLocalPort, a.sshConfig.AdditionalArgs)
+			if err != nil {
+				return err
+			}
+		}
+		if err := a.runAnsiblePlaybook(ctx, inventoryPath, p); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+	}
+	return mErr
+}
+
+func (a *HostAgent) runAnsiblePlaybook(ctx context.Context, inventoryPath string, p limayaml.Provision) error {
+	cmd, err := ansible.PlaybookCommand(ctx, inventoryPath, p.Playbook)
+	if err != nil {
+		return fmt.Errorf("failed to run the playbook %q of provision %q: %w", p.Playbook, p.Name, err)
+	}
+	w := logrus.WithField("provision", p.Name).WriterLevel(logrus.InfoLevel)
+	defer w.Close()
+	cmd.Stdout = w
+	cmd.Stderr = w
+	logrus.Infof("Running the playbook %q of provision %q", p.Playbook, p.Name)
+	logrus.Debugf("cmd.Args: %v", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run the playbook %q of provision %q: %w", p.Playbook, p.Name, err)
+	}
+	return nil
+}
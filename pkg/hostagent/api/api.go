@@ -1,5 +1,38 @@
 package api
 
+import (
+	"time"
+)
+
 type Info struct {
 	SSHLocalPort int `json:"sshLocalPort,omitempty"`
 }
+
+// PortForward is an active forward from the guest to the host.
+type PortForward struct {
+	// Protocol is "tcp", "udp", or "unix"
+	Protocol string `json:"protocol"`
+	// GuestAddress is "IP:PORT" for "tcp" and "udp", or the socket path for "unix"
+	GuestAddress string `json:"guestAddress"`
+	// HostAddress is "IP:PORT" or the socket path
+	HostAddress string `json:"hostAddress"`
+}
+
+// Mount is the status of a mount in `mounts`.
+type Mount struct {
+	Location string `json:"location"`
+	Writable bool   `json:"writable,omitempty"`
+	Mounted  bool   `json:"mounted,omitempty"`
+	// Error is set when the mount failed
+	Error string `json:"error,omitempty"`
+}
+
+// Probe is the latest state of a liveness probe in `probes`.
+type Probe struct {
+	Description string `json:"description"`
+	Healthy     bool   `json:"healthy,omitempty"`
+	// Error is set when the probe failed
+	Error string `json:"error,omitempty"`
+	// LastChecked is zero until the probe is executed for the first time
+	LastChecked time.Time `json:"lastChecked,omitempty"`
+}
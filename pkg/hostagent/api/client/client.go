@@ -4,18 +4,28 @@ package client
 // Apache License 2.0
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/lima-vm/lima/pkg/hostagent/api"
+	"github.com/lima-vm/lima/pkg/hostagent/events"
 	"github.com/lima-vm/lima/pkg/httpclientutil"
+	"github.com/lima-vm/lima/pkg/limayaml"
 )
 
 type HostAgentClient interface {
 	HTTPClient() *http.Client
 	Info(context.Context) (*api.Info, error)
+	Events(context.Context, func(events.Event)) error
+	Ports(context.Context) ([]api.PortForward, error)
+	Mounts(context.Context) ([]api.Mount, error)
+	Probes(context.Context) ([]api.Probe, error)
+	PortForwards(context.Context) ([]limayaml.PortForward, error)
+	AddPortForward(context.Context, limayaml.PortForward) error
+	RemovePortForward(context.Context, limayaml.PortForward) error
 }
 
 // NewHostAgentClient creates a client.
@@ -62,3 +72,108 @@ func (c *client) Info(ctx context.Context) (*api.Info, error) {
 	}
 	return &info, nil
 }
+
+// Events calls onEvent for each event until ctx is done or the connection is closed.
+// The first event is the latest event emitted by the host agent.
+func (c *client) Events(ctx context.Context, onEvent func(events.Event)) error {
+	u := fmt.Sprintf("http://%s/%s/events", c.dummyHost, c.version)
+	resp, err := httpclientutil.Get(ctx, c.HTTPClient(), u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var ev events.Event
+		if err := dec.Decode(&ev); err != nil {
+			return err
+		}
+		onEvent(ev)
+	}
+}
+
+func (c *client) Ports(ctx context.Context) ([]api.PortForward, error) {
+	u := fmt.Sprintf("http://%s/%s/ports", c.dummyHost, c.version)
+	resp, err := httpclientutil.Get(ctx, c.HTTPClient(), u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ports []api.PortForward
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&ports); err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
+func (c *client) Mounts(ctx context.Context) ([]api.Mount, error) {
+	u := fmt.Sprintf("http://%s/%s/mounts", c.dummyHost, c.version)
+	resp, err := httpclientutil.Get(ctx, c.HTTPClient(), u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var mounts []api.Mount
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&mounts); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+func (c *client) Probes(ctx context.Context) ([]api.Probe, error) {
+	u := fmt.Sprintf("http://%s/%s/probes", c.dummyHost, c.version)
+	resp, err := httpclientutil.Get(ctx, c.HTTPClient(), u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var probes []api.Probe
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&probes); err != nil {
+		return nil, err
+	}
+	return probes, nil
+}
+
+func (c *client) PortForwards(ctx context.Context) ([]limayaml.PortForward, error) {
+	u := fmt.Sprintf("http://%s/%s/portforwards", c.dummyHost, c.version)
+	resp, err := httpclientutil.Get(ctx, c.HTTPClient(), u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var rules []limayaml.PortForward
+	dec := json.NewDecoder(resp.Body)
+	if err := dec.Decode(&rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (c *client) AddPortForward(ctx context.Context, rule limayaml.PortForward) error {
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("http://%s/%s/portforwards", c.dummyHost, c.version)
+	resp, err := httpclientutil.Post(ctx, c.HTTPClient(), u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *client) RemovePortForward(ctx context.Context, rule limayaml.PortForward) error {
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("http://%s/%s/portforwards", c.dummyHost, c.version)
+	resp, err := httpclientutil.Delete(ctx, c.HTTPClient(), u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -3,11 +3,15 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/lima-vm/lima/pkg/hostagent"
+	"github.com/lima-vm/lima/pkg/hostagent/events"
 	"github.com/lima-vm/lima/pkg/httputil"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/sirupsen/logrus"
 )
 
 type Backend struct {
@@ -46,7 +50,149 @@ func (b *Backend) GetInfo(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(m)
 }
 
+// GetEvents is the handler for GET /v{N}/events.
+func (b *Backend) GetEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		panic("http.ResponseWriter has to implement http.Flusher")
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan events.Event)
+	go b.Agent.Events(ctx, ch)
+
+	enc := json.NewEncoder(w)
+	for ev := range ch {
+		if err := enc.Encode(ev); err != nil {
+			logrus.Warn(err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// GetPorts is the handler for GET /v{N}/ports
+func (b *Backend) GetPorts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ports, err := b.Agent.Ports(ctx)
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	b.writeJSON(w, r, ports)
+}
+
+// GetMounts is the handler for GET /v{N}/mounts
+func (b *Backend) GetMounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mounts, err := b.Agent.Mounts(ctx)
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	b.writeJSON(w, r, mounts)
+}
+
+// GetProbes is the handler for GET /v{N}/probes
+func (b *Backend) GetProbes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	probes, err := b.Agent.Probes(ctx)
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	b.writeJSON(w, r, probes)
+}
+
+// GetPortForwards is the handler for GET /v{N}/portforwards
+func (b *Backend) GetPortForwards(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rules, err := b.Agent.PortForwards(ctx)
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	b.writeJSON(w, r, rules)
+}
+
+// PostPortForward is the handler for POST /v{N}/portforwards
+func (b *Backend) PostPortForward(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var rule limayaml.PortForward
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		b.onError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if err := b.Agent.AddPortForward(ctx, rule); err != nil {
+		b.onError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeletePortForward is the handler for DELETE /v{N}/portforwards
+func (b *Backend) DeletePortForward(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var rule limayaml.PortForward
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		b.onError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if err := b.Agent.RemovePortForward(ctx, rule); err != nil {
+		ec := http.StatusInternalServerError
+		if errors.Is(err, hostagent.ErrPortForwardNotFound) {
+			ec = http.StatusNotFound
+		}
+		b.onError(w, r, err, ec)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *Backend) writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	m, err := json.Marshal(v)
+	if err != nil {
+		b.onError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(m)
+}
+
 func AddRoutes(r *mux.Router, b *Backend) {
 	v1 := r.PathPrefix("/v1").Subrouter()
 	v1.Path("/info").Methods("GET").HandlerFunc(b.GetInfo)
+	v1.Path("/events").Methods("GET").HandlerFunc(b.GetEvents)
+	v1.Path("/ports").Methods("GET").HandlerFunc(b.GetPorts)
+	v1.Path("/mounts").Methods("GET").HandlerFunc(b.GetMounts)
+	v1.Path("/probes").Methods("GET").HandlerFunc(b.GetProbes)
+	v1.Path("/portforwards").Methods("GET").HandlerFunc(b.GetPortForwards)
+	v1.Path("/portforwards").Methods("POST").HandlerFunc(b.PostPortForward)
+	v1.Path("/portforwards").Methods("DELETE").HandlerFunc(b.DeletePortForward)
 }
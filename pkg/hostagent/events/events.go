@@ -4,7 +4,34 @@ import (
 	"time"
 )
 
+// Phase is the progress of the startup.
+type Phase = string
+
+const (
+	PhaseBooting     Phase = "booting"      // QEMU has been started
+	PhaseSSHReady    Phase = "ssh-ready"    // the guest is accessible via SSH
+	PhaseMountsReady Phase = "mounts-ready" // the essential requirements are satisfied, and the mounts are set up
+	PhaseProvisioned Phase = "provisioned"  // the provisioning scripts and the ansible playbooks have succeeded
+	PhaseReady       Phase = "ready"        // the optional requirements are satisfied (Running is true)
+)
+
+// Phases is the list of the phases, in the order of the startup.
+var Phases = []Phase{PhaseBooting, PhaseSSHReady, PhaseMountsReady, PhaseProvisioned, PhaseReady}
+
+// PhaseIndex returns the index of the phase in Phases, or -1 for an unknown phase.
+func PhaseIndex(phase Phase) int {
+	for i, p := range Phases {
+		if p == phase {
+			return i
+		}
+	}
+	return -1
+}
+
 type Status struct {
+	// Phase is the latest phase of the startup
+	Phase Phase `json:"phase,omitempty"`
+
 	Running bool `json:"running,omitempty"`
 	// When Degraded is true, Running must be true as well
 	Degraded bool `json:"degraded,omitempty"`
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPhaseIndex(t *testing.T) {
+	for i, phase := range Phases {
+		assert.Equal(t, i, PhaseIndex(phase))
+	}
+	assert.Assert(t, PhaseIndex(PhaseBooting) < PhaseIndex(PhaseSSHReady))
+	assert.Assert(t, PhaseIndex(PhaseSSHReady) < PhaseIndex(PhaseMountsReady))
+	assert.Assert(t, PhaseIndex(PhaseMountsReady) < PhaseIndex(PhaseProvisioned))
+	assert.Assert(t, PhaseIndex(PhaseProvisioned) < PhaseIndex(PhaseReady))
+	assert.Equal(t, -1, PhaseIndex(""))
+	assert.Equal(t, -1, PhaseIndex("unknown"))
+	assert.Equal(t, -1, PhaseIndex("Ready"))
+}
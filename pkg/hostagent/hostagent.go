@@ -43,12 +43,23 @@ type HostAgent struct {
 	portForwarder   *portForwarder
 	onClose         []func() error // LIFO
 
+	qCfg     qemu.Config
 	qExe     string
 	qArgs    []string
 	sigintCh chan os.Signal
 
-	eventEnc   *json.Encoder
-	eventEncMu sync.Mutex
+	eventEnc    *json.Encoder
+	eventEncMu  sync.Mutex
+	latestEvent *events.Event
+	eventSubs   map[chan events.Event]struct{}
+
+	bootTimeout time.Duration
+
+	mountStatus   []hostagentapi.Mount
+	mountStatusMu sync.RWMutex
+
+	probeStatus   []hostagentapi.Probe // liveness probes only
+	probeStatusMu sync.RWMutex
 }
 
 type options struct {
@@ -117,6 +128,11 @@ func New(instName string, stdout io.Writer, sigintCh chan os.Signal, opts ...Opt
 		return nil, err
 	}
 
+	bootTimeout, err := time.ParseDuration(y.BootTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bootTimeout %q: %w", y.BootTimeout, err)
+	}
+
 	sshOpts, err := sshutil.SSHOpts(inst.Dir, *y.SSH.LoadDotSSHPubKeys, *y.SSH.ForwardAgent)
 	if err != nil {
 		return nil, err
@@ -125,13 +141,8 @@ func New(instName string, stdout io.Writer, sigintCh chan os.Signal, opts ...Opt
 		AdditionalArgs: sshutil.SSHArgsFromOpts(sshOpts),
 	}
 
-	rules := make([]limayaml.PortForward, 0, 3+len(y.PortForwards))
-	// Block ports 22 and sshLocalPort on all IPs
-	for _, port := range []int{sshGuestPort, sshLocalPort} {
-		rule := limayaml.PortForward{GuestIP: net.IPv4zero, GuestPort: port, Ignore: true}
-		limayaml.FillPortForwardDefaults(&rule, inst.Dir)
-		rules = append(rules, rule)
-	}
+	// The rules for ports 22 and sshLocalPort are prepended by newPortForwarder
+	rules := make([]limayaml.PortForward, 0, 1+len(y.PortForwards))
 	rules = append(rules, y.PortForwards...)
 	// Default forwards for all non-privileged ports from "127.0.0.1" and "::1"
 	rule := limayaml.PortForward{GuestIP: guestagentapi.IPv4loopback1}
@@ -145,11 +156,25 @@ func New(instName string, stdout io.Writer, sigintCh chan os.Signal, opts ...Opt
 		tcpDNSLocalPort: tcpDNSLocalPort,
 		instDir:         inst.Dir,
 		sshConfig:       sshConfig,
-		portForwarder:   newPortForwarder(sshConfig, sshLocalPort, rules),
+		bootTimeout:     bootTimeout,
+		portForwarder:   newPortForwarder(sshConfig, sshLocalPort, filepath.Join(inst.Dir, filenames.GuestAgentSock), rules),
+		qCfg:            qCfg,
 		qExe:            qExe,
 		qArgs:           qArgs,
 		sigintCh:        sigintCh,
 		eventEnc:        json.NewEncoder(stdout),
+		eventSubs:       make(map[chan events.Event]struct{}),
+	}
+	for _, m := range y.Mounts {
+		a.mountStatus = append(a.mountStatus, hostagentapi.Mount{
+			Location: m.Location,
+			Writable: m.Writable,
+		})
+	}
+	for _, probe := range a.livenessProbes() {
+		a.probeStatus = append(a.probeStatus, hostagentapi.Probe{
+			Description: probe.Description,
+		})
 	}
 	return a, nil
 }
@@ -227,6 +252,54 @@ func (a *HostAgent) emitEvent(ctx context.Context, ev events.Event) {
 	if err := a.eventEnc.Encode(ev); err != nil {
 		logrus.WithField("event", ev).WithError(err).Error("failed to emit an event")
 	}
+	a.latestEvent = &ev
+	for sub := range a.eventSubs {
+		select {
+		case sub <- ev:
+		default:
+			logrus.WithField("event", ev).Warn("dropping an event for a slow API client")
+		}
+	}
+}
+
+// emitPhase emits an event of the startup phase, before the "running" event.
+func (a *HostAgent) emitPhase(ctx context.Context, phase events.Phase) {
+	a.emitEvent(ctx, events.Event{
+		Status: events.Status{
+			Phase:        phase,
+			SSHLocalPort: a.sshLocalPort,
+		},
+	})
+}
+
+// Events sends the events to ch until ctx is done.
+// The first event is the latest event emitted before calling Events.
+func (a *HostAgent) Events(ctx context.Context, ch chan events.Event) {
+	defer close(ch)
+	sub := make(chan events.Event, 16)
+	a.eventEncMu.Lock()
+	if a.latestEvent != nil {
+		sub <- *a.latestEvent
+	}
+	a.eventSubs[sub] = struct{}{}
+	a.eventEncMu.Unlock()
+	defer func() {
+		a.eventEncMu.Lock()
+		delete(a.eventSubs, sub)
+		a.eventEncMu.Unlock()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-sub:
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- ev:
+			}
+		}
+	}
 }
 
 func logPipeRoutine(r io.Reader, header string) {
@@ -267,6 +340,23 @@ func (a *HostAgent) Run(ctx context.Context) error {
 	}
 	go logPipeRoutine(qStderr, "qemu[stderr]")
 
+	// Run returns after QEMU exits, so the disks can be used by other instances then
+	defer func() {
+		if err := store.UnlockDisks(a.instDir, a.y); err != nil {
+			logrus.WithError(err).Warn("failed to unlock the additional disks")
+		}
+	}()
+
+	// QEMU restores the VM state when the file exists (see qemu.Cmdline)
+	_, vmStateErr := os.Stat(filepath.Join(a.instDir, filenames.VMState))
+	restoring := vmStateErr == nil
+	if !restoring {
+		// The marker was left by a suspended QEMU that has exited without saving the state
+		if err := os.RemoveAll(filepath.Join(a.instDir, filenames.SuspendedMarker)); err != nil {
+			return err
+		}
+	}
+
 	logrus.Infof("Starting QEMU (hint: to watch the boot progress, see %q)", filepath.Join(a.instDir, filenames.SerialLog))
 	logrus.Debugf("qCmd.Args: %v", qCmd.Args)
 	if err := qCmd.Start(); err != nil {
@@ -276,11 +366,25 @@ func (a *HostAgent) Run(ctx context.Context) error {
 	go func() {
 		qWaitCh <- qCmd.Wait()
 	}()
+	// Kill QEMU when Run returns before QEMU exits, e.g., on a failure in restoring the VM state
+	qExited := false
+	defer func() {
+		if !qExited {
+			_ = a.killQEMU(ctx, 0, qCmd, qWaitCh)
+		}
+	}()
+	if restoring {
+		logrus.Info("Restoring the VM state saved by `limactl suspend --save`")
+		if err := qemu.ResumeRestoredState(a.qCfg, a.bootTimeout); err != nil {
+			return err
+		}
+	}
 
 	stBase := events.Status{
 		SSHLocalPort: a.sshLocalPort,
 	}
 	stBooting := stBase
+	stBooting.Phase = events.PhaseBooting
 	a.emitEvent(ctx, events.Event{Status: stBooting})
 
 	ctxHA, cancelHA := context.WithCancel(ctx)
@@ -291,7 +395,9 @@ func (a *HostAgent) Run(ctx context.Context) error {
 			stRunning.Errors = append(stRunning.Errors, haErr.Error())
 		}
 		stRunning.Running = true
+		stRunning.Phase = events.PhaseReady
 		a.emitEvent(ctx, events.Event{Status: stRunning})
+		a.watchLivenessProbes(ctxHA, stRunning)
 	}()
 
 	for {
@@ -302,8 +408,11 @@ func (a *HostAgent) Run(ctx context.Context) error {
 			if closeErr := a.close(); closeErr != nil {
 				logrus.WithError(closeErr).Warn("an error during shutting down the host agent")
 			}
+			// shutdownQEMU waits for QEMU to exit
+			qExited = true
 			return a.shutdownQEMU(ctx, 3*time.Minute, qCmd, qWaitCh)
 		case qWaitErr := <-qWaitCh:
+			qExited = true
 			logrus.WithError(qWaitErr).Info("QEMU has exited")
 			// lint insists that we need to call cancelHA() on all possible codepaths
 			cancelHA()
@@ -311,6 +420,7 @@ func (a *HostAgent) Run(ctx context.Context) error {
 		}
 	}
 }
+
 func (a *HostAgent) Info(ctx context.Context) (*hostagentapi.Info, error) {
 	info := &hostagentapi.Info{
 		SSHLocalPort: a.sshLocalPort,
@@ -318,6 +428,45 @@ func (a *HostAgent) Info(ctx context.Context) (*hostagentapi.Info, error) {
 	return info, nil
 }
 
+// Ports returns the active port forwards.
+func (a *HostAgent) Ports(ctx context.Context) ([]hostagentapi.PortForward, error) {
+	return a.portForwarder.Forwards(), nil
+}
+
+// AddPortForward adds the port forwarding rule without restarting the instance.
+// The rule is not persisted to lima.yaml.
+func (a *HostAgent) AddPortForward(ctx context.Context, rule limayaml.PortForward) error {
+	if rule.GuestSocket != "" || rule.HostSocket != "" {
+		return errors.New("field `guestSocket` and field `hostSocket` are not supported for port forwarding rules added via the API")
+	}
+	limayaml.FillPortForwardDefaults(&rule, a.instDir)
+	if err := limayaml.ValidatePortForward("portForward", rule); err != nil {
+		return err
+	}
+	a.portForwarder.AddRule(rule)
+	return nil
+}
+
+// RemovePortForward removes the port forwarding rule added by AddPortForward.
+func (a *HostAgent) RemovePortForward(ctx context.Context, rule limayaml.PortForward) error {
+	limayaml.FillPortForwardDefaults(&rule, a.instDir)
+	return a.portForwarder.RemoveRule(rule)
+}
+
+// PortForwards returns the port forwarding rules added by AddPortForward.
+func (a *HostAgent) PortForwards(ctx context.Context) ([]limayaml.PortForward, error) {
+	return a.portForwarder.DynamicRules(), nil
+}
+
+// Mounts returns the status of the mounts.
+func (a *HostAgent) Mounts(ctx context.Context) ([]hostagentapi.Mount, error) {
+	a.mountStatusMu.RLock()
+	defer a.mountStatusMu.RUnlock()
+	res := make([]hostagentapi.Mount, len(a.mountStatus))
+	copy(res, a.mountStatus)
+	return res, nil
+}
+
 func (a *HostAgent) shutdownQEMU(ctx context.Context, timeout time.Duration, qCmd *exec.Cmd, qWaitCh <-chan error) error {
 	logrus.Info("Shutting down QEMU with ACPI")
 	qmpSockPath := filepath.Join(a.instDir, filenames.QMPSock)
@@ -332,6 +481,10 @@ func (a *HostAgent) shutdownQEMU(ctx context.Context, timeout time.Duration, qCm
 	}
 	defer func() { _ = qmpClient.Disconnect() }()
 	rawClient := raw.NewMonitor(qmpClient)
+	// The guest does not respond to ACPI while suspended by `limactl suspend`
+	if err := rawClient.Cont(); err != nil {
+		logrus.WithError(err).Debug("failed to send QMP cont command")
+	}
 	logrus.Info("Sending QMP system_powerdown command")
 	if err := rawClient.SystemPowerdown(); err != nil {
 		logrus.WithError(err).Warnf("failed to send system_powerdown command via the QMP socket %q, forcibly killing QEMU", qmpSockPath)
@@ -367,9 +520,13 @@ func (a *HostAgent) startHostAgentRoutines(ctx context.Context) error {
 		}
 		return nil
 	})
+	// bootCtx bounds the requirements, so that `limactl start` does not hang forever
+	bootCtx, cancelBoot := context.WithTimeout(ctx, a.bootTimeout)
+	defer cancelBoot()
 	var mErr error
-	if err := a.waitForRequirements(ctx, "essential", a.essentialRequirements()); err != nil {
-		mErr = multierror.Append(mErr, err)
+	essentialErr := a.waitForRequirements(bootCtx, "essential", a.essentialRequirements())
+	if essentialErr != nil {
+		mErr = multierror.Append(mErr, essentialErr)
 	}
 	mounts, err := a.setupMounts(ctx)
 	if err != nil {
@@ -385,7 +542,24 @@ func (a *HostAgent) startHostAgentRoutines(ctx context.Context) error {
 		return unmountMErr
 	})
 	go a.watchGuestAgentEvents(ctx)
-	if err := a.waitForRequirements(ctx, "optional", a.optionalRequirements()); err != nil {
+	// The phases are not emitted after failures, so that `limactl start --wait=PHASE` does not return prematurely
+	if mErr == nil {
+		a.emitPhase(ctx, events.PhaseMountsReady)
+	}
+	if err := a.waitForRequirements(bootCtx, "provisioning", a.provisioningRequirements()); err != nil {
+		mErr = multierror.Append(mErr, err)
+	}
+	if essentialErr == nil {
+		if err := a.runAnsiblePlaybooks(ctx); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+	} else {
+		logrus.Warn("Skipping the ansible playbooks, as the essential requirements are not satisfied")
+	}
+	if mErr == nil {
+		a.emitPhase(ctx, events.PhaseProvisioned)
+	}
+	if err := a.waitForRequirements(bootCtx, "optional", a.optionalRequirements()); err != nil {
 		mErr = multierror.Append(mErr, err)
 	}
 	return mErr
@@ -411,7 +585,9 @@ func (a *HostAgent) watchGuestAgentEvents(ctx context.Context) {
 	for _, rule := range a.y.PortForwards {
 		if rule.GuestSocket != "" {
 			local := hostAddress(rule, guestagentapi.IPPort{})
-			_ = forwardSSH(ctx, a.sshConfig, a.sshLocalPort, local, rule.GuestSocket, verbForward)
+			if err := forwardSSH(ctx, a.sshConfig, a.sshLocalPort, local, rule.GuestSocket, verbForward); err == nil {
+				a.portForwarder.record(protoUnix, local, rule.GuestSocket, verbForward)
+			}
 		}
 	}
 
@@ -428,6 +604,7 @@ func (a *HostAgent) watchGuestAgentEvents(ctx context.Context) {
 				if err := forwardSSH(context.Background(), a.sshConfig, a.sshLocalPort, local, rule.GuestSocket, verbCancel); err != nil {
 					mErr = multierror.Append(mErr, err)
 				}
+				a.portForwarder.record(protoUnix, local, rule.GuestSocket, verbCancel)
 			}
 		}
 		if err := forwardSSH(context.Background(), a.sshConfig, a.sshLocalPort, localUnix, remoteUnix, verbCancel); err != nil {
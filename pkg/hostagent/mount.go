@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/alessio/shellescape"
 	"github.com/hashicorp/go-multierror"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	"github.com/lima-vm/lima/pkg/localpathutil"
@@ -21,17 +23,63 @@ func (a *HostAgent) setupMounts(ctx context.Context) ([]*mount, error) {
 		res  []*mount
 		mErr error
 	)
-	for _, f := range a.y.Mounts {
+	if a.y.MountType == limayaml.NINEP {
+		// 9p mounts are attached as virtfs devices by QEMU, and mounted by the guest via /etc/fstab
+		for i, f := range a.y.Mounts {
+			if err := a.check9pMount(ctx, f); err != nil {
+				a.setMountStatus(i, false, err)
+				mErr = multierror.Append(mErr, err)
+				continue
+			}
+			a.setMountStatus(i, true, nil)
+		}
+		return nil, mErr
+	}
+	for i, f := range a.y.Mounts {
 		m, err := a.setupMount(ctx, f)
 		if err != nil {
+			a.setMountStatus(i, false, err)
 			mErr = multierror.Append(mErr, err)
 			continue
 		}
+		a.setMountStatus(i, true, nil)
+		i, mClose := i, m.close
+		m.close = func() error {
+			a.setMountStatus(i, false, nil)
+			return mClose()
+		}
 		res = append(res, m)
 	}
 	return res, mErr
 }
 
+func (a *HostAgent) setMountStatus(i int, mounted bool, err error) {
+	a.mountStatusMu.Lock()
+	defer a.mountStatusMu.Unlock()
+	a.mountStatus[i].Mounted = mounted
+	a.mountStatus[i].Error = ""
+	if err != nil {
+		a.mountStatus[i].Error = err.Error()
+	}
+}
+
+const check9pMountTimeout = 30 * time.Second
+
+// check9pMount checks that the 9p mount has been mounted by the guest, waiting for check9pMountTimeout.
+func (a *HostAgent) check9pMount(ctx context.Context, m limayaml.Mount) error {
+	script := fmt.Sprintf(`#!/bin/sh
+for i in $(seq %d); do
+	mountpoint -q %s && exit 0
+	sleep 1
+done
+exit 1
+`, int(check9pMountTimeout/time.Second), shellescape.Quote(m.MountPoint))
+	if _, _, err := executeScript(ctx, a.sshLocalPort, a.sshConfig, script, "check 9p mount "+m.MountPoint); err != nil {
+		return fmt.Errorf("9p mount %q is not mounted in the guest: %w", m.MountPoint, err)
+	}
+	return nil
+}
+
 func (a *HostAgent) setupMount(ctx context.Context, m limayaml.Mount) (*mount, error) {
 	expanded, err := localpathutil.Expand(m.Location)
 	if err != nil {
@@ -40,16 +88,23 @@ func (a *HostAgent) setupMount(ctx context.Context, m limayaml.Mount) (*mount, e
 	if err := os.MkdirAll(expanded, 0755); err != nil {
 		return nil, err
 	}
-	logrus.Infof("Mounting %q", expanded)
+	logrus.Infof("Mounting %q on %q", expanded, m.MountPoint)
+	var sshfsOptions []string
+	if !*m.SSHFS.Cache {
+		sshfsOptions = append(sshfsOptions, "-o", "cache=no")
+	}
+	if *m.SSHFS.FollowSymlinks {
+		sshfsOptions = append(sshfsOptions, "-o", "follow_symlinks")
+	}
 	rsf := &reversesshfs.ReverseSSHFS{
 		SSHConfig:  a.sshConfig,
 		LocalPath:  expanded,
 		Host:       "127.0.0.1",
 		Port:       a.sshLocalPort,
-		RemotePath: expanded,
+		RemotePath: m.MountPoint,
 		Readonly:   !m.Writable,
 		// NOTE: allow_other requires "user_allow_other" in /etc/fuse.conf
-		SSHFSAdditionalArgs: []string{"-o", "allow_other"},
+		SSHFSAdditionalArgs: append([]string{"-o", "allow_other"}, sshfsOptions...),
 	}
 	if err := rsf.Prepare(); err != nil {
 		return nil, fmt.Errorf("failed to prepare reverse sshfs for %q: %w", expanded, err)
@@ -57,7 +112,7 @@ func (a *HostAgent) setupMount(ctx context.Context, m limayaml.Mount) (*mount, e
 	if err := rsf.Start(); err != nil {
 		logrus.WithError(err).Warnf("failed to mount reverse sshfs for %q, retrying with `-o nonempty`", expanded)
 		// NOTE: nonempty is not supported for libfuse3: https://github.com/canonical/multipass/issues/1381
-		rsf.SSHFSAdditionalArgs = []string{"-o", "nonempty"}
+		rsf.SSHFSAdditionalArgs = append([]string{"-o", "nonempty"}, sshfsOptions...)
 		if err := rsf.Start(); err != nil {
 			return nil, fmt.Errorf("failed to mount reverse sshfs for %q: %w", expanded, err)
 		}
@@ -2,27 +2,66 @@ package hostagent
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/lima-vm/lima/pkg/guestagent/api"
+	guestagentclient "github.com/lima-vm/lima/pkg/guestagent/api/client"
+	hostagentapi "github.com/lima-vm/lima/pkg/hostagent/api"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	"github.com/lima-vm/sshocker/pkg/ssh"
 	"github.com/sirupsen/logrus"
 )
 
 type portForwarder struct {
-	sshConfig   *ssh.SSHConfig
-	sshHostPort int
-	rules       []limayaml.PortForward
+	sshConfig      *ssh.SSHConfig
+	sshHostPort    int
+	guestAgentSock string
+	udpForwarders  map[string]*udpForwarder // key: local address
+
+	// mu protects the fields below, and serializes setting up and tearing down forwards
+	mu            sync.Mutex
+	reservedRules []limayaml.PortForward // rules for the SSH ports, cannot be overridden
+	dynamicRules  []limayaml.PortForward // rules added via the API, evaluated before rules
+	rules         []limayaml.PortForward
+	guestPorts    map[string]api.IPPort // key: protocol + ":" + guest address
+	ctx           context.Context       // the context of the latest OnEvent call
+
+	forwards   map[string]hostagentapi.PortForward // key: protocol + ":" + local address
+	forwardsMu sync.RWMutex
 }
 
 const sshGuestPort = 22
 
-func newPortForwarder(sshConfig *ssh.SSHConfig, sshHostPort int, rules []limayaml.PortForward) *portForwarder {
+// protoUnix is the protocol of the forwards of `guestSocket`
+const protoUnix = "unix"
+
+// ErrPortForwardNotFound is returned by RemoveRule when the rule was not added by AddRule.
+var ErrPortForwardNotFound = errors.New("port forward rule not found")
+
+func newPortForwarder(sshConfig *ssh.SSHConfig, sshHostPort int, guestAgentSock string, rules []limayaml.PortForward) *portForwarder {
+	// Block ports 22 and sshLocalPort on all IPs
+	var reservedRules []limayaml.PortForward
+	for _, port := range []int{sshGuestPort, sshHostPort} {
+		rule := limayaml.PortForward{GuestIP: net.IPv4zero, GuestPort: port, Ignore: true}
+		limayaml.FillPortForwardDefaults(&rule, "")
+		reservedRules = append(reservedRules, rule)
+	}
 	return &portForwarder{
-		sshConfig:   sshConfig,
-		sshHostPort: sshHostPort,
-		rules:       rules,
+		sshConfig:      sshConfig,
+		sshHostPort:    sshHostPort,
+		guestAgentSock: guestAgentSock,
+		udpForwarders:  make(map[string]*udpForwarder),
+		reservedRules:  reservedRules,
+		rules:          rules,
+		guestPorts:     make(map[string]api.IPPort),
+		forwards:       make(map[string]hostagentapi.PortForward),
 	}
 }
 
@@ -40,15 +79,34 @@ func hostAddress(rule limayaml.PortForward, guest api.IPPort) string {
 	return host.String()
 }
 
+// guestProto returns the protocol of guest, falling back to TCP for guest agents that do not report the protocol.
+func guestProto(guest api.IPPort) limayaml.Proto {
+	if guest.Protocol == "" {
+		return limayaml.TCP
+	}
+	return guest.Protocol
+}
+
+// forwardingAddresses must be called with pf.mu held.
 func (pf *portForwarder) forwardingAddresses(guest api.IPPort) (string, string) {
-	for _, rule := range pf.rules {
+	rules := make([]limayaml.PortForward, 0, len(pf.reservedRules)+len(pf.dynamicRules)+len(pf.rules))
+	rules = append(rules, pf.reservedRules...)
+	rules = append(rules, pf.dynamicRules...)
+	rules = append(rules, pf.rules...)
+	for _, rule := range rules {
 		if rule.GuestSocket != "" {
 			continue
 		}
+		if rule.Proto != guestProto(guest) {
+			continue
+		}
 		if guest.Port < rule.GuestPortRange[0] || guest.Port > rule.GuestPortRange[1] {
 			continue
 		}
 		switch {
+		case guest.IP.Equal(net.IPv4zero) && rule.GuestIP.To4() == nil && !rule.GuestIP.IsUnspecified():
+			// "0.0.0.0" is not reachable via IPv6 addresses such as "::1"
+			continue
 		case guest.IP.IsUnspecified():
 		case guest.IP.Equal(rule.GuestIP):
 		case guest.IP.Equal(net.IPv6loopback) && rule.GuestIP.Equal(api.IPv4loopback1):
@@ -67,26 +125,160 @@ func (pf *portForwarder) forwardingAddresses(guest api.IPPort) (string, string)
 	return "", guest.String()
 }
 
+func (pf *portForwarder) forward(ctx context.Context, proto limayaml.Proto, local, remote string, verb string) error {
+	if proto == limayaml.UDP {
+		return pf.forwardUDP(ctx, local, remote, verb)
+	}
+	return forwardTCP(ctx, pf.sshConfig, pf.sshHostPort, local, remote, verb)
+}
+
+// record records the forward as active (verbForward) or inactive (verbCancel).
+func (pf *portForwarder) record(proto, local, remote string, verb string) {
+	pf.forwardsMu.Lock()
+	defer pf.forwardsMu.Unlock()
+	k := proto + ":" + local
+	switch verb {
+	case verbForward:
+		pf.forwards[k] = hostagentapi.PortForward{
+			Protocol:     proto,
+			GuestAddress: remote,
+			HostAddress:  local,
+		}
+	case verbCancel:
+		delete(pf.forwards, k)
+	default:
+		panic(fmt.Errorf("invalid verb %q", verb))
+	}
+}
+
+// Forwards returns the active forwards, sorted by the host address.
+func (pf *portForwarder) Forwards() []hostagentapi.PortForward {
+	pf.forwardsMu.RLock()
+	defer pf.forwardsMu.RUnlock()
+	res := make([]hostagentapi.PortForward, 0, len(pf.forwards))
+	for _, f := range pf.forwards {
+		res = append(res, f)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].HostAddress != res[j].HostAddress {
+			return res[i].HostAddress < res[j].HostAddress
+		}
+		return res[i].Protocol < res[j].Protocol
+	})
+	return res
+}
+
+func (pf *portForwarder) dialUDP(ctx context.Context, remote string) (io.ReadWriteCloser, error) {
+	client, err := guestagentclient.NewGuestAgentClient(pf.guestAgentSock)
+	if err != nil {
+		return nil, err
+	}
+	return client.DialUDP(ctx, remote)
+}
+
 func (pf *portForwarder) OnEvent(ctx context.Context, ev api.Event) {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	pf.ctx = ctx
+	for _, f := range ev.LocalPortsRemoved {
+		delete(pf.guestPorts, guestProto(f)+":"+f.String())
+	}
+	for _, f := range ev.LocalPortsAdded {
+		pf.guestPorts[guestProto(f)+":"+f.String()] = f
+	}
 	for _, f := range ev.LocalPortsRemoved {
 		local, remote := pf.forwardingAddresses(f)
 		if local == "" {
 			continue
 		}
-		logrus.Infof("Stopping forwarding TCP from %s to %s", remote, local)
-		if err := forwardTCP(ctx, pf.sshConfig, pf.sshHostPort, local, remote, verbCancel); err != nil {
-			logrus.WithError(err).Warnf("failed to stop forwarding tcp port %d", f.Port)
+		proto := guestProto(f)
+		logrus.Infof("Stopping forwarding %s from %s to %s", strings.ToUpper(proto), remote, local)
+		if err := pf.forward(ctx, proto, local, remote, verbCancel); err != nil {
+			logrus.WithError(err).Warnf("failed to stop forwarding %s port %d", proto, f.Port)
 		}
+		pf.record(proto, local, remote, verbCancel)
 	}
 	for _, f := range ev.LocalPortsAdded {
 		local, remote := pf.forwardingAddresses(f)
+		proto := guestProto(f)
 		if local == "" {
-			logrus.Infof("Not forwarding TCP %s", remote)
+			logrus.Infof("Not forwarding %s %s", strings.ToUpper(proto), remote)
 			continue
 		}
-		logrus.Infof("Forwarding TCP from %s to %s", remote, local)
-		if err := forwardTCP(ctx, pf.sshConfig, pf.sshHostPort, local, remote, verbForward); err != nil {
-			logrus.WithError(err).Warnf("failed to set up forwarding tcp port %d (negligible if already forwarded)", f.Port)
+		logrus.Infof("Forwarding %s from %s to %s", strings.ToUpper(proto), remote, local)
+		if err := pf.forward(ctx, proto, local, remote, verbForward); err != nil {
+			logrus.WithError(err).Warnf("failed to set up forwarding %s port %d (negligible if already forwarded)", proto, f.Port)
+			continue
+		}
+		pf.record(proto, local, remote, verbForward)
+	}
+}
+
+// AddRule adds the rule, and forwards the guest ports that are already being listened on.
+// The rule is evaluated before the rules in lima.yaml, but after the rules for the SSH ports.
+func (pf *portForwarder) AddRule(rule limayaml.PortForward) {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	pf.updateRules(func() {
+		pf.dynamicRules = append([]limayaml.PortForward{rule}, pf.dynamicRules...)
+	})
+}
+
+// RemoveRule removes the rule added by AddRule, and stops the forwards that no longer match.
+func (pf *portForwarder) RemoveRule(rule limayaml.PortForward) error {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	for i, r := range pf.dynamicRules {
+		if reflect.DeepEqual(r, rule) {
+			pf.updateRules(func() {
+				pf.dynamicRules = append(pf.dynamicRules[:i:i], pf.dynamicRules[i+1:]...)
+			})
+			return nil
+		}
+	}
+	return ErrPortForwardNotFound
+}
+
+// DynamicRules returns the rules added by AddRule.
+func (pf *portForwarder) DynamicRules() []limayaml.PortForward {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	return append([]limayaml.PortForward{}, pf.dynamicRules...)
+}
+
+// updateRules calls f to update the rules, and re-evaluates the forwards of the guest ports.
+// updateRules must be called with pf.mu held.
+func (pf *portForwarder) updateRules(f func()) {
+	oldLocals := make(map[string]string, len(pf.guestPorts))
+	for k, guest := range pf.guestPorts {
+		oldLocals[k], _ = pf.forwardingAddresses(guest)
+	}
+	f()
+	if pf.ctx == nil {
+		// no guest port has been reported yet
+		return
+	}
+	for k, guest := range pf.guestPorts {
+		oldLocal := oldLocals[k]
+		local, remote := pf.forwardingAddresses(guest)
+		if local == oldLocal {
+			continue
+		}
+		proto := guestProto(guest)
+		if oldLocal != "" {
+			logrus.Infof("Stopping forwarding %s from %s to %s", strings.ToUpper(proto), remote, oldLocal)
+			if err := pf.forward(pf.ctx, proto, oldLocal, remote, verbCancel); err != nil {
+				logrus.WithError(err).Warnf("failed to stop forwarding %s port %d", proto, guest.Port)
+			}
+			pf.record(proto, oldLocal, remote, verbCancel)
+		}
+		if local != "" {
+			logrus.Infof("Forwarding %s from %s to %s", strings.ToUpper(proto), remote, local)
+			if err := pf.forward(pf.ctx, proto, local, remote, verbForward); err != nil {
+				logrus.WithError(err).Warnf("failed to set up forwarding %s port %d", proto, guest.Port)
+				continue
+			}
+			pf.record(proto, local, remote, verbForward)
 		}
 	}
 }
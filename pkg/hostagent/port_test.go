@@ -0,0 +1,111 @@
+package hostagent
+
+import (
+	"net"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/guestagent/api"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestAddRemoveRule(t *testing.T) {
+	var rule limayaml.PortForward
+	limayaml.FillPortForwardDefaults(&rule, "")
+	pf := newPortForwarder(nil, 60022, "", []limayaml.PortForward{rule})
+	guest := api.IPPort{IP: net.IPv4(127, 0, 0, 1), Port: 80, Protocol: limayaml.TCP}
+
+	forwardingAddress := func() string {
+		pf.mu.Lock()
+		defer pf.mu.Unlock()
+		local, _ := pf.forwardingAddresses(guest)
+		return local
+	}
+	assert.Equal(t, "127.0.0.1:80", forwardingAddress())
+
+	ruleA := limayaml.PortForward{GuestPort: 80, HostPort: 8080}
+	limayaml.FillPortForwardDefaults(&ruleA, "")
+	ruleB := limayaml.PortForward{GuestPort: 80, HostPort: 8081}
+	limayaml.FillPortForwardDefaults(&ruleB, "")
+	ignore := limayaml.PortForward{GuestPort: 80, Ignore: true}
+	limayaml.FillPortForwardDefaults(&ignore, "")
+
+	pf.AddRule(ruleA)
+	assert.Equal(t, "127.0.0.1:8080", forwardingAddress())
+	// the rule added last takes precedence
+	pf.AddRule(ruleB)
+	assert.Equal(t, "127.0.0.1:8081", forwardingAddress())
+	assert.DeepEqual(t, []limayaml.PortForward{ruleB, ruleA}, pf.DynamicRules())
+
+	assert.NilError(t, pf.RemoveRule(ruleB))
+	assert.Equal(t, "127.0.0.1:8080", forwardingAddress())
+	assert.Equal(t, ErrPortForwardNotFound, pf.RemoveRule(ruleB))
+	assert.NilError(t, pf.RemoveRule(ruleA))
+	assert.Equal(t, "127.0.0.1:80", forwardingAddress())
+	assert.Equal(t, 0, len(pf.DynamicRules()))
+
+	pf.AddRule(ignore)
+	assert.Equal(t, "", forwardingAddress())
+	assert.NilError(t, pf.RemoveRule(ignore))
+
+	// the rules for the SSH ports cannot be overridden
+	ssh := limayaml.PortForward{GuestPort: sshGuestPort, HostPort: 2222}
+	limayaml.FillPortForwardDefaults(&ssh, "")
+	pf.AddRule(ssh)
+	pf.mu.Lock()
+	local, _ := pf.forwardingAddresses(api.IPPort{IP: net.IPv4(127, 0, 0, 1), Port: sshGuestPort, Protocol: limayaml.TCP})
+	pf.mu.Unlock()
+	assert.Equal(t, "", local)
+}
+
+func TestForwardingAddresses(t *testing.T) {
+	// guestIP "::1" is reachable from the IPv6 guest addresses only
+	ipv6Loopback := limayaml.PortForward{GuestIP: net.IPv6loopback, GuestPort: 8080, HostIP: net.IPv6loopback}
+	limayaml.FillPortForwardDefaults(&ipv6Loopback, "")
+	// guestIP "::" is the same as "0.0.0.0"
+	ipv6Any := limayaml.PortForward{GuestIP: net.IPv6unspecified, GuestPort: 9090, HostIP: net.IPv4zero}
+	limayaml.FillPortForwardDefaults(&ipv6Any, "")
+	ipv4Any := limayaml.PortForward{GuestIP: net.IPv4zero, GuestPort: 7070, HostIP: net.IPv4zero}
+	limayaml.FillPortForwardDefaults(&ipv4Any, "")
+	var defaultRule limayaml.PortForward
+	limayaml.FillPortForwardDefaults(&defaultRule, "")
+	pf := newPortForwarder(nil, 60022, "", []limayaml.PortForward{ipv6Loopback, ipv6Any, ipv4Any, defaultRule})
+
+	type testCase struct {
+		guestIP  string
+		port     int
+		expected string
+	}
+	testCases := []testCase{
+		// IPv4 guest addresses
+		{guestIP: "127.0.0.1", port: 80, expected: "127.0.0.1:80"},
+		{guestIP: "0.0.0.0", port: 80, expected: "127.0.0.1:80"},
+		{guestIP: "192.168.5.15", port: 80, expected: ""},
+		{guestIP: "0.0.0.0", port: 7070, expected: "0.0.0.0:7070"},
+		{guestIP: "192.168.5.15", port: 7070, expected: "0.0.0.0:7070"},
+		{guestIP: "0.0.0.0", port: 9090, expected: "0.0.0.0:9090"},
+		// "0.0.0.0" is not reachable via "::1", so the next rule is used
+		{guestIP: "0.0.0.0", port: 8080, expected: "127.0.0.1:8080"},
+		{guestIP: "127.0.0.1", port: 8080, expected: "127.0.0.1:8080"},
+		// IPv6 guest addresses
+		{guestIP: "::1", port: 80, expected: "127.0.0.1:80"},
+		{guestIP: "::", port: 80, expected: "127.0.0.1:80"},
+		{guestIP: "fd00::1", port: 80, expected: ""},
+		{guestIP: "::1", port: 8080, expected: "[::1]:8080"},
+		{guestIP: "::", port: 8080, expected: "[::1]:8080"},
+		{guestIP: "::", port: 9090, expected: "0.0.0.0:9090"},
+		{guestIP: "fd00::1", port: 9090, expected: "0.0.0.0:9090"},
+		{guestIP: "fd00::1", port: 7070, expected: "0.0.0.0:7070"},
+		// the SSH ports are never forwarded
+		{guestIP: "::", port: sshGuestPort, expected: ""},
+		{guestIP: "0.0.0.0", port: sshGuestPort, expected: ""},
+	}
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	for _, tc := range testCases {
+		guest := api.IPPort{IP: net.ParseIP(tc.guestIP), Port: tc.port, Protocol: limayaml.TCP}
+		local, remote := pf.forwardingAddresses(guest)
+		assert.Equal(t, tc.expected, local, guest.String())
+		assert.Equal(t, guest.String(), remote)
+	}
+}
@@ -0,0 +1,164 @@
+package hostagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lima-vm/lima/pkg/udptunnel"
+	"github.com/sirupsen/logrus"
+)
+
+// udpSessionIdleTimeout is the duration after which an idle UDP session is closed.
+const udpSessionIdleTimeout = time.Minute
+
+// forwardUDP relays datagrams sent to the local address to the remote address in the guest.
+// `ssh -L` cannot carry UDP, so the datagrams are tunneled through the guest agent socket instead.
+//
+// forwardUDP is not thread-safe
+func (pf *portForwarder) forwardUDP(ctx context.Context, local, remote string, verb string) error {
+	switch verb {
+	case verbForward:
+		if _, ok := pf.udpForwarders[local]; ok {
+			return fmt.Errorf("%q is already forwarded", local)
+		}
+		uf, err := newUDPForwarder(local, remote, pf.dialUDP)
+		if err != nil {
+			return err
+		}
+		pf.udpForwarders[local] = uf
+		go func() {
+			if ufErr := uf.Serve(ctx); ufErr != nil && !errors.Is(ufErr, net.ErrClosed) {
+				logrus.WithError(ufErr).Warnf("UDP forwarder for %q crashed", local)
+			}
+		}()
+	case verbCancel:
+		uf, ok := pf.udpForwarders[local]
+		if !ok {
+			logrus.Warnf("forwarding for %q seems already cancelled?", local)
+			return nil
+		}
+		delete(pf.udpForwarders, local)
+		return uf.Close()
+	default:
+		panic(fmt.Errorf("invalid verb %q", verb))
+	}
+	return nil
+}
+
+type udpForwarder struct {
+	ln     *net.UDPConn
+	remote string
+	dial   func(ctx context.Context, remote string) (io.ReadWriteCloser, error)
+
+	sessions   map[string]*udpSession // key: client address
+	sessionsMu sync.Mutex
+}
+
+// udpSession corresponds to a client address on the host.
+type udpSession struct {
+	stream io.ReadWriteCloser
+	timer  *time.Timer
+}
+
+func newUDPForwarder(local, remote string, dial func(context.Context, string) (io.ReadWriteCloser, error)) (*udpForwarder, error) {
+	lnAddr, err := net.ResolveUDPAddr("udp", local)
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.ListenUDP("udp", lnAddr)
+	if err != nil {
+		return nil, err
+	}
+	uf := &udpForwarder{
+		ln:       ln,
+		remote:   remote,
+		dial:     dial,
+		sessions: make(map[string]*udpSession),
+	}
+	return uf, nil
+}
+
+func (uf *udpForwarder) Serve(ctx context.Context) error {
+	buf := make([]byte, udptunnel.MaxDatagramSize)
+	for {
+		n, src, err := uf.ln.ReadFromUDP(buf)
+		if err != nil {
+			return err
+		}
+		sess, err := uf.session(ctx, src)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to relay a UDP datagram from %s to %s (guest)", src, uf.remote)
+			continue
+		}
+		if err := udptunnel.WriteDatagram(sess.stream, buf[:n]); err != nil {
+			logrus.WithError(err).Debugf("failed to relay a UDP datagram from %s to %s (guest)", src, uf.remote)
+			uf.closeSession(src.String(), sess)
+			continue
+		}
+		sess.timer.Reset(udpSessionIdleTimeout)
+	}
+}
+
+func (uf *udpForwarder) session(ctx context.Context, src *net.UDPAddr) (*udpSession, error) {
+	k := src.String()
+	uf.sessionsMu.Lock()
+	defer uf.sessionsMu.Unlock()
+	if sess, ok := uf.sessions[k]; ok {
+		return sess, nil
+	}
+	stream, err := uf.dial(ctx, uf.remote)
+	if err != nil {
+		return nil, err
+	}
+	sess := &udpSession{stream: stream}
+	sess.timer = time.AfterFunc(udpSessionIdleTimeout, func() {
+		uf.closeSession(k, sess)
+	})
+	uf.sessions[k] = sess
+	go uf.reply(k, sess, src)
+	return sess, nil
+}
+
+// reply relays the datagrams from the guest back to the client address src.
+func (uf *udpForwarder) reply(k string, sess *udpSession, src *net.UDPAddr) {
+	defer uf.closeSession(k, sess)
+	buf := make([]byte, udptunnel.MaxDatagramSize)
+	for {
+		n, err := udptunnel.ReadDatagram(sess.stream, buf)
+		if err != nil {
+			return
+		}
+		if _, err := uf.ln.WriteToUDP(buf[:n], src); err != nil {
+			return
+		}
+		sess.timer.Reset(udpSessionIdleTimeout)
+	}
+}
+
+func (uf *udpForwarder) closeSession(k string, sess *udpSession) {
+	uf.sessionsMu.Lock()
+	if uf.sessions[k] == sess {
+		delete(uf.sessions, k)
+	}
+	uf.sessionsMu.Unlock()
+	sess.timer.Stop()
+	_ = sess.stream.Close()
+}
+
+func (uf *udpForwarder) Close() error {
+	err := uf.ln.Close()
+	uf.sessionsMu.Lock()
+	sessions := uf.sessions
+	uf.sessions = make(map[string]*udpSession)
+	uf.sessionsMu.Unlock()
+	for _, sess := range sessions {
+		sess.timer.Stop()
+		_ = sess.stream.Close()
+	}
+	return err
+}
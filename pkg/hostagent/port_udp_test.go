@@ -0,0 +1,76 @@
+package hostagent
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lima-vm/lima/pkg/udptunnel"
+	"gotest.tools/v3/assert"
+)
+
+func TestUDPForwarder(t *testing.T) {
+	// echo server, in place of the UDP server in the guest
+	echo, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NilError(t, err)
+	defer echo.Close()
+	go func() {
+		buf := make([]byte, udptunnel.MaxDatagramSize)
+		for {
+			n, src, err := echo.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteToUDP(buf[:n], src)
+		}
+	}()
+
+	// dial tunnels the datagrams in memory, in place of the guest agent
+	var (
+		dialed   []string
+		dialedMu sync.Mutex
+	)
+	dial := func(ctx context.Context, remote string) (io.ReadWriteCloser, error) {
+		dialedMu.Lock()
+		dialed = append(dialed, remote)
+		dialedMu.Unlock()
+		hostSide, guestSide := net.Pipe()
+		go func() {
+			_ = udptunnel.Serve(guestSide, remote)
+		}()
+		return hostSide, nil
+	}
+
+	uf, err := newUDPForwarder("127.0.0.1:0", echo.LocalAddr().String(), dial)
+	assert.NilError(t, err)
+	defer uf.Close()
+	go func() {
+		_ = uf.Serve(context.Background())
+	}()
+
+	client, err := net.DialUDP("udp", nil, uf.ln.LocalAddr().(*net.UDPAddr))
+	assert.NilError(t, err)
+	defer client.Close()
+	buf := make([]byte, udptunnel.MaxDatagramSize)
+	for _, msg := range []string{"hello", "world"} {
+		_, err = client.Write([]byte(msg))
+		assert.NilError(t, err)
+		assert.NilError(t, client.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, err := client.Read(buf)
+		assert.NilError(t, err)
+		assert.Equal(t, msg, string(buf[:n]))
+	}
+
+	// The datagrams from the same client address share a session
+	dialedMu.Lock()
+	assert.DeepEqual(t, []string{echo.LocalAddr().String()}, dialed)
+	dialedMu.Unlock()
+
+	assert.NilError(t, uf.Close())
+	uf.sessionsMu.Lock()
+	assert.Equal(t, 0, len(uf.sessions))
+	uf.sessionsMu.Unlock()
+}
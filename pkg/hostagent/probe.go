@@ -0,0 +1,129 @@
+package hostagent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	hostagentapi "github.com/lima-vm/lima/pkg/hostagent/api"
+	"github.com/lima-vm/lima/pkg/hostagent/events"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+)
+
+func (a *HostAgent) livenessProbes() []limayaml.Probe {
+	var res []limayaml.Probe
+	for _, probe := range a.y.Probes {
+		if probe.Mode == limayaml.ProbeModeLiveness {
+			res = append(res, probe)
+		}
+	}
+	return res
+}
+
+// watchLivenessProbes executes each liveness probe every `interval` until ctx is done.
+// When the health of a probe changes, an event is emitted with Degraded set
+// (in addition to stRunning.Degraded) if any probe is failing.
+func (a *HostAgent) watchLivenessProbes(ctx context.Context, stRunning events.Status) {
+	var wg sync.WaitGroup
+	for i, probe := range a.livenessProbes() {
+		wg.Add(1)
+		go func(i int, probe limayaml.Probe) {
+			defer wg.Done()
+			a.watchLivenessProbe(ctx, stRunning, i, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+}
+
+func (a *HostAgent) watchLivenessProbe(ctx context.Context, stRunning events.Status, i int, probe limayaml.Probe) {
+	// validated in limayaml.Validate
+	timeout, _ := time.ParseDuration(probe.Timeout)
+	interval, _ := time.ParseDuration(probe.Interval)
+	for {
+		a.runLivenessProbe(ctx, stRunning, i, probe, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (a *HostAgent) runLivenessProbe(ctx context.Context, stRunning events.Status, i int, probe limayaml.Probe, timeout time.Duration) {
+	// The probe would time out while the vCPUs are paused by `limactl suspend`
+	if a.suspended() {
+		return
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := a.waitForRequirement(execCtx, requirement{
+		description: probe.Description,
+		script:      probe.Script,
+		debugHint:   probe.Hint,
+	})
+	cancel()
+	// The instance might have been suspended during the execution
+	if ctx.Err() != nil || a.suspended() {
+		return
+	}
+	if a.setProbeStatus(i, err) {
+		if err != nil {
+			logrus.WithError(err).Warnf("The liveness probe %q failed", probe.Description)
+		} else {
+			logrus.Infof("The liveness probe %q recovered", probe.Description)
+		}
+		a.emitEvent(ctx, events.Event{Status: a.livenessStatus(stRunning)})
+	}
+}
+
+// suspended returns whether the vCPUs are paused by `limactl suspend`.
+func (a *HostAgent) suspended() bool {
+	_, err := os.Stat(filepath.Join(a.instDir, filenames.SuspendedMarker))
+	return err == nil
+}
+
+// livenessStatus returns stRunning with the errors of the failing liveness probes.
+func (a *HostAgent) livenessStatus(stRunning events.Status) events.Status {
+	a.probeStatusMu.RLock()
+	defer a.probeStatusMu.RUnlock()
+	st := stRunning
+	st.Errors = append([]string{}, stRunning.Errors...)
+	for _, probe := range a.probeStatus {
+		if !probe.Healthy && !probe.LastChecked.IsZero() {
+			st.Degraded = true
+			st.Errors = append(st.Errors, fmt.Sprintf("liveness probe %q failed: %s", probe.Description, probe.Error))
+		}
+	}
+	return st
+}
+
+// setProbeStatus records the result of the probe, and returns whether the health has changed.
+// The probes are assumed to be healthy before the first execution, as the "running" event has been emitted without them.
+func (a *HostAgent) setProbeStatus(i int, err error) bool {
+	a.probeStatusMu.Lock()
+	defer a.probeStatusMu.Unlock()
+	wasHealthy := a.probeStatus[i].Healthy || a.probeStatus[i].LastChecked.IsZero()
+	a.probeStatus[i].Healthy = err == nil
+	a.probeStatus[i].Error = ""
+	if err != nil {
+		a.probeStatus[i].Error = err.Error()
+	}
+	a.probeStatus[i].LastChecked = time.Now()
+	return wasHealthy != a.probeStatus[i].Healthy
+}
+
+// Probes returns the latest state of the liveness probes.
+func (a *HostAgent) Probes(ctx context.Context) ([]hostagentapi.Probe, error) {
+	a.probeStatusMu.RLock()
+	defer a.probeStatusMu.RUnlock()
+	res := make([]hostagentapi.Probe, len(a.probeStatus))
+	copy(res, a.probeStatus)
+	return res, nil
+}
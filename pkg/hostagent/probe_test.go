@@ -0,0 +1,74 @@
+package hostagent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	hostagentapi "github.com/lima-vm/lima/pkg/hostagent/api"
+	"github.com/lima-vm/lima/pkg/hostagent/events"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunLivenessProbeSuspended(t *testing.T) {
+	a := &HostAgent{
+		instDir:     t.TempDir(),
+		probeStatus: []hostagentapi.Probe{{Description: "foo"}},
+	}
+	assert.NilError(t, os.WriteFile(filepath.Join(a.instDir, filenames.SuspendedMarker), nil, 0644))
+	// the probe is not executed (the SSH config is not set), and the status is not updated
+	a.runLivenessProbe(context.Background(), events.Status{Running: true}, 0, limayaml.Probe{Description: "foo", Script: "#!/bin/true"}, 0)
+	probes, err := a.Probes(context.Background())
+	assert.NilError(t, err)
+	assert.Assert(t, probes[0].LastChecked.IsZero())
+}
+
+func TestSetProbeStatus(t *testing.T) {
+	a := &HostAgent{
+		probeStatus: []hostagentapi.Probe{{Description: "foo"}, {Description: "bar"}},
+	}
+	stRunning := events.Status{Running: true, Errors: []string{"running with an error"}, SSHLocalPort: 60022}
+
+	// the probes are healthy before the first execution
+	assert.DeepEqual(t, stRunning, a.livenessStatus(stRunning))
+
+	// the first success does not change the health
+	assert.Assert(t, !a.setProbeStatus(0, nil))
+	assert.Assert(t, a.probeStatus[0].Healthy)
+	assert.Assert(t, !a.probeStatus[0].LastChecked.IsZero())
+
+	// the first failure flips the health
+	assert.Assert(t, a.setProbeStatus(1, errors.New("bar failed")))
+	assert.Assert(t, !a.probeStatus[1].Healthy)
+	assert.Equal(t, "bar failed", a.probeStatus[1].Error)
+	// an unchanged result emits nothing
+	assert.Assert(t, !a.setProbeStatus(1, errors.New("bar failed again")))
+	assert.Equal(t, "bar failed again", a.probeStatus[1].Error)
+
+	assert.Assert(t, a.setProbeStatus(0, errors.New("foo failed")))
+	st := a.livenessStatus(stRunning)
+	assert.Assert(t, st.Running)
+	assert.Assert(t, st.Degraded)
+	assert.Equal(t, 60022, st.SSHLocalPort)
+	assert.DeepEqual(t, []string{
+		"running with an error",
+		`liveness probe "foo" failed: foo failed`,
+		`liveness probe "bar" failed: bar failed again`,
+	}, st.Errors)
+	// stRunning is not modified
+	assert.DeepEqual(t, []string{"running with an error"}, stRunning.Errors)
+
+	// the recovery flips the health back
+	assert.Assert(t, a.setProbeStatus(0, nil))
+	assert.Equal(t, "", a.probeStatus[0].Error)
+	st = a.livenessStatus(stRunning)
+	assert.Assert(t, st.Degraded)
+	assert.DeepEqual(t, []string{"running with an error", `liveness probe "bar" failed: bar failed again`}, st.Errors)
+
+	assert.Assert(t, a.setProbeStatus(1, nil))
+	assert.DeepEqual(t, stRunning, a.livenessStatus(stRunning))
+}
@@ -1,49 +1,79 @@
 package hostagent
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/lima-vm/lima/pkg/hostagent/events"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	"github.com/lima-vm/sshocker/pkg/ssh"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultRequirementInterval is the interval between the retries of a requirement.
+const defaultRequirementInterval = 10 * time.Second
+
+// waitForRequirements waits for the requirements until ctx is done.
+// The deadline of ctx (typically `bootTimeout`) is applied to the requirements without timeout.
 func (a *HostAgent) waitForRequirements(ctx context.Context, label string, requirements []requirement) error {
-	const (
-		retries       = 60
-		sleepDuration = 10 * time.Second
-	)
 	var mErr error
 
 	for i, req := range requirements {
-	retryLoop:
-		for j := 0; j < retries; j++ {
-			logrus.Infof("Waiting for the %s requirement %d of %d: %q", label, i+1, len(requirements), req.description)
-			err := a.waitForRequirement(ctx, req)
-			if err == nil {
-				logrus.Infof("The %s requirement %d of %d is satisfied", label, i+1, len(requirements))
-				break retryLoop
-			}
+		if err := a.waitForRequirementWithRetries(ctx, label, i, len(requirements), req); err != nil {
+			mErr = multierror.Append(mErr, err)
 			if req.fatal {
 				logrus.Infof("No further %s requirements will be checked", label)
-				return multierror.Append(mErr, fmt.Errorf("failed to satisfy the %s requirement %d of %d %q: %s; skipping further checks: %w", label, i+1, len(requirements), req.description, req.debugHint, err))
+				return mErr
 			}
-			if j == retries-1 {
-				mErr = multierror.Append(mErr, fmt.Errorf("failed to satisfy the %s requirement %d of %d %q: %s: %w", label, i+1, len(requirements), req.description, req.debugHint, err))
-				break retryLoop
+			if ctx.Err() != nil {
+				logrus.Infof("No further %s requirements will be checked: %v", label, ctx.Err())
+				return mErr
 			}
-			time.Sleep(10 * time.Second)
 		}
 	}
 	return mErr
 }
 
+func (a *HostAgent) waitForRequirementWithRetries(ctx context.Context, label string, i, n int, req requirement) error {
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+	}
+	interval := req.interval
+	if interval <= 0 {
+		interval = defaultRequirementInterval
+	}
+	for {
+		logrus.Infof("Waiting for the %s requirement %d of %d: %q", label, i+1, n, req.description)
+		err := a.waitForRequirement(ctx, req)
+		if err == nil {
+			logrus.Infof("The %s requirement %d of %d is satisfied", label, i+1, n)
+			if req.phase != "" {
+				a.emitPhase(ctx, req.phase)
+			}
+			return nil
+		}
+		if req.fatal {
+			return fmt.Errorf("failed to satisfy the %s requirement %d of %d %q: %s; skipping further checks: %w", label, i+1, n, req.description, req.debugHint, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to satisfy the %s requirement %d of %d %q (%v): %s: %w", label, i+1, n, req.description, ctx.Err(), req.debugHint, err)
+		case <-time.After(interval):
+		}
+	}
+}
+
 func (a *HostAgent) waitForRequirement(ctx context.Context, r requirement) error {
 	logrus.Debugf("executing script %q", r.description)
-	stdout, stderr, err := ssh.ExecuteScript("127.0.0.1", a.sshLocalPort, a.sshConfig, r.script, r.description)
+	stdout, stderr, err := executeScript(ctx, a.sshLocalPort, a.sshConfig, r.script, r.description)
 	logrus.Debugf("stdout=%q, stderr=%q, err=%v", stdout, stderr, err)
 	if err != nil {
 		return fmt.Errorf("stdout=%q, stderr=%q: %w", stdout, stderr, err)
@@ -51,11 +81,34 @@ func (a *HostAgent) waitForRequirement(ctx context.Context, r requirement) error
 	return nil
 }
 
+// executeScript is similar to ssh.ExecuteScript, but kills the ssh process when ctx is done.
+func executeScript(ctx context.Context, port int, c *ssh.SSHConfig, script, scriptName string) (string, string, error) {
+	interpreter, err := ssh.ParseScriptInterpreter(script)
+	if err != nil {
+		return "", "", err
+	}
+	sshArgs := append(c.Args(), "-p", strconv.Itoa(port), "127.0.0.1", "--", interpreter)
+	sshCmd := exec.CommandContext(ctx, c.Binary(), sshArgs...)
+	sshCmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	sshCmd.Stderr = &stderr
+	logrus.Debugf("executing ssh for script %q: %s %v", scriptName, sshCmd.Path, sshCmd.Args)
+	out, err := sshCmd.Output()
+	if err != nil {
+		return string(out), stderr.String(), fmt.Errorf("failed to execute script %q: stdout=%q, stderr=%q: %w",
+			scriptName, string(out), stderr.String(), err)
+	}
+	return string(out), stderr.String(), nil
+}
+
 type requirement struct {
 	description string
 	script      string
 	debugHint   string
 	fatal       bool
+	timeout     time.Duration // zero means the deadline of the context
+	interval    time.Duration // zero means defaultRequirementInterval
+	phase       events.Phase  // emitted when the requirement is satisfied, if not empty
 }
 
 func (a *HostAgent) essentialRequirements() []requirement {
@@ -65,6 +118,7 @@ func (a *HostAgent) essentialRequirements() []requirement {
 		script: `#!/bin/bash
 true
 `,
+		phase: events.PhaseSSHReady,
 		debugHint: `Failed to SSH into the guest.
 Make sure that the YAML field "ssh.localPort" is not used by other processes on the host.
 If any private key under ~/.ssh is protected with a passphrase, you need to have ssh-agent to be running.
@@ -118,6 +172,41 @@ A possible workaround is to run "lima-guestagent install-systemd" in the guest.
 	return req
 }
 
+func (a *HostAgent) provisioningRequirements() []requirement {
+	return []requirement{
+		{
+			description: "the boot scripts and the provisioning scripts to be finished",
+			script: `#!/bin/bash
+set -eux -o pipefail
+if ! timeout 30s bash -c "until [ -e /run/lima-boot-done ]; do sleep 3; done"; then
+	echo >&2 "the boot scripts and the provisioning scripts are still running"
+	exit 1
+fi
+`,
+			debugHint: `The boot scripts and the provisioning scripts did not finish in time.
+See "/var/log/cloud-init-output.log" in the guest.
+Consider increasing "bootTimeout" in lima.yaml if the provisioning scripts take long.
+`,
+		},
+		{
+			description: "the boot scripts and the provisioning scripts to be successful",
+			script: `#!/bin/bash
+set -eux -o pipefail
+code="$(cat /run/lima-boot-done)"
+if [ "${code}" != "0" ]; then
+	echo >&2 "the boot scripts or the provisioning scripts failed (exit code ${code})"
+	exit 1
+fi
+`,
+			// The result does not change on retries
+			fatal: true,
+			debugHint: `The boot scripts or the provisioning scripts failed.
+See "/var/log/cloud-init-output.log" in the guest, or run ` + "`limactl logs --source=cloud-init INSTANCE`" + `.
+`,
+		},
+	}
+}
+
 func (a *HostAgent) optionalRequirements() []requirement {
 	req := make([]requirement, 0)
 	if *a.y.Containerd.System || *a.y.Containerd.User {
@@ -155,10 +244,15 @@ Also see "/var/log/cloud-init-output.log" in the guest.
 	}
 	for _, probe := range a.y.Probes {
 		if probe.Mode == limayaml.ProbeModeReadiness {
+			// validated in limayaml.Validate
+			timeout, _ := time.ParseDuration(probe.Timeout)
+			interval, _ := time.ParseDuration(probe.Interval)
 			req = append(req, requirement{
 				description: probe.Description,
 				script:      probe.Script,
 				debugHint:   probe.Hint,
+				timeout:     timeout,
+				interval:    interval,
 			})
 		}
 	}
@@ -35,10 +35,27 @@ func NewHTTPClientWithSocketPath(socketPath string) (*http.Client, error) {
 
 // Get calls HTTP GET and verifies that the status code is 2XX .
 func Get(ctx context.Context, c *http.Client, url string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	return do(ctx, c, "GET", url, nil)
+}
+
+// Post calls HTTP POST with the JSON body and verifies that the status code is 2XX .
+func Post(ctx context.Context, c *http.Client, url string, body io.Reader) (*http.Response, error) {
+	return do(ctx, c, "POST", url, body)
+}
+
+// Delete calls HTTP DELETE with the JSON body and verifies that the status code is 2XX .
+func Delete(ctx context.Context, c *http.Client, url string, body io.Reader) (*http.Response, error) {
+	return do(ctx, c, "DELETE", url, body)
+}
+
+func do(ctx context.Context, c *http.Client, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
@@ -10,9 +10,11 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/lima-vm/lima/pkg/guestagent/api"
+	"github.com/lima-vm/lima/pkg/localpathutil"
 	"github.com/lima-vm/lima/pkg/osutil"
 	"github.com/lima-vm/lima/pkg/store/dirnames"
 	"github.com/lima-vm/lima/pkg/store/filenames"
@@ -68,6 +70,33 @@ func FillDefault(y *LimaYAML, filePath string) {
 	if y.Disk == "" {
 		y.Disk = "100GiB"
 	}
+	for i := range y.AdditionalDisks {
+		disk := &y.AdditionalDisks[i]
+		if disk.MountPoint == "" {
+			disk.MountPoint = "/mnt/lima-" + disk.Name
+		}
+		if disk.FSType == "" {
+			disk.FSType = EXT4
+		}
+	}
+	for i := range y.Mounts {
+		mount := &y.Mounts[i]
+		if mount.MountPoint == "" {
+			// the guest path is the same as the host path by default
+			if location, err := localpathutil.Expand(mount.Location); err == nil {
+				mount.MountPoint = location
+			}
+		}
+		if mount.SSHFS.Cache == nil {
+			mount.SSHFS.Cache = &[]bool{true}[0]
+		}
+		if mount.SSHFS.FollowSymlinks == nil {
+			mount.SSHFS.FollowSymlinks = &[]bool{false}[0]
+		}
+	}
+	if y.MountType == "" {
+		y.MountType = REVSSHFS
+	}
 	if y.Video.Display == "" {
 		y.Video.Display = "none"
 	}
@@ -83,6 +112,24 @@ func FillDefault(y *LimaYAML, filePath string) {
 		if provision.Mode == "" {
 			provision.Mode = ProvisionModeSystem
 		}
+		if provision.Name == "" {
+			provision.Name = fmt.Sprintf("provision-%d", i)
+		}
+		if provision.When == "" {
+			provision.When = ProvisionWhenAlways
+		}
+		// Resolve a relative playbook path against the instance directory, so that it does not depend on the current directory
+		if provision.Playbook != "" && !filepath.IsAbs(provision.Playbook) && !strings.HasPrefix(provision.Playbook, "~") {
+			provision.Playbook = filepath.Join(filepath.Dir(filePath), provision.Playbook)
+		}
+		if provision.Mode == ProvisionModeData {
+			if provision.Owner == "" {
+				provision.Owner = "root:root"
+			}
+			if provision.Permissions == "" {
+				provision.Permissions = "644"
+			}
+		}
 	}
 	if y.Containerd.System == nil {
 		y.Containerd.System = &[]bool{false}[0]
@@ -99,6 +146,9 @@ func FillDefault(y *LimaYAML, filePath string) {
 			f.Arch = y.Arch
 		}
 	}
+	if y.BootTimeout == "" {
+		y.BootTimeout = "10m"
+	}
 	for i := range y.Probes {
 		probe := &y.Probes[i]
 		if probe.Mode == "" {
@@ -107,6 +157,22 @@ func FillDefault(y *LimaYAML, filePath string) {
 		if probe.Description == "" {
 			probe.Description = fmt.Sprintf("user probe %d/%d", i+1, len(y.Probes))
 		}
+		switch probe.Mode {
+		case ProbeModeReadiness:
+			if probe.Timeout == "" {
+				probe.Timeout = y.BootTimeout
+			}
+			if probe.Interval == "" {
+				probe.Interval = "10s"
+			}
+		case ProbeModeLiveness:
+			if probe.Timeout == "" {
+				probe.Timeout = "30s"
+			}
+			if probe.Interval == "" {
+				probe.Interval = "30s"
+			}
+		}
 	}
 	instDir := filepath.Dir(filePath)
 	for i := range y.PortForwards {
@@ -10,15 +10,21 @@ type LimaYAML struct {
 	Arch              Arch              `yaml:"arch,omitempty" json:"arch,omitempty"`
 	Images            []File            `yaml:"images" json:"images"` // REQUIRED
 	CPUs              int               `yaml:"cpus,omitempty" json:"cpus,omitempty"`
+	CPUType           map[Arch]string   `yaml:"cpuType,omitempty" json:"cpuType,omitempty"`
 	Memory            string            `yaml:"memory,omitempty" json:"memory,omitempty"` // go-units.RAMInBytes
 	Disk              string            `yaml:"disk,omitempty" json:"disk,omitempty"`     // go-units.RAMInBytes
+	AdditionalDisks   []AdditionalDisk  `yaml:"additionalDisks,omitempty" json:"additionalDisks,omitempty"`
 	Mounts            []Mount           `yaml:"mounts,omitempty" json:"mounts,omitempty"`
+	MountType         MountType         `yaml:"mountType,omitempty" json:"mountType,omitempty"`
+	WorkDir           string            `yaml:"workDir,omitempty" json:"workDir,omitempty"`
 	SSH               SSH               `yaml:"ssh,omitempty" json:"ssh,omitempty"` // REQUIRED (FIXME)
 	Firmware          Firmware          `yaml:"firmware,omitempty" json:"firmware,omitempty"`
 	Video             Video             `yaml:"video,omitempty" json:"video,omitempty"`
+	VMOpts            VMOpts            `yaml:"vmOpts,omitempty" json:"vmOpts,omitempty"`
 	Provision         []Provision       `yaml:"provision,omitempty" json:"provision,omitempty"`
 	Containerd        Containerd        `yaml:"containerd,omitempty" json:"containerd,omitempty"`
 	Probes            []Probe           `yaml:"probes,omitempty" json:"probes,omitempty"`
+	BootTimeout       string            `yaml:"bootTimeout,omitempty" json:"bootTimeout,omitempty"` // duration, e.g., "10m"
 	PortForwards      []PortForward     `yaml:"portForwards,omitempty" json:"portForwards,omitempty"`
 	Networks          []Network         `yaml:"networks,omitempty" json:"networks,omitempty"`
 	Network           NetworkDeprecated `yaml:"network,omitempty" json:"network,omitempty"` // DEPRECATED, use `networks` instead
@@ -35,15 +41,43 @@ const (
 	AARCH64 Arch = "aarch64"
 )
 
+type MountType = string
+
+const (
+	REVSSHFS MountType = "reverse-sshfs"
+	NINEP    MountType = "9p"
+)
+
 type File struct {
 	Location string        `yaml:"location" json:"location"` // REQUIRED
 	Arch     Arch          `yaml:"arch,omitempty" json:"arch,omitempty"`
 	Digest   digest.Digest `yaml:"digest,omitempty" json:"digest,omitempty"`
 }
 
+// AdditionalDisk refers to a disk created with `limactl disk create`.
+type AdditionalDisk struct {
+	Name       string `yaml:"name" json:"name"` // REQUIRED
+	MountPoint string `yaml:"mountPoint,omitempty" json:"mountPoint,omitempty"`
+	FSType     FSType `yaml:"fsType,omitempty" json:"fsType,omitempty"`
+}
+
+type FSType = string
+
+const (
+	EXT4 FSType = "ext4"
+	XFS  FSType = "xfs"
+)
+
 type Mount struct {
-	Location string `yaml:"location" json:"location"` // REQUIRED
-	Writable bool   `yaml:"writable,omitempty" json:"writable,omitempty"`
+	Location   string `yaml:"location" json:"location"` // REQUIRED
+	MountPoint string `yaml:"mountPoint,omitempty" json:"mountPoint,omitempty"`
+	Writable   bool   `yaml:"writable,omitempty" json:"writable,omitempty"`
+	SSHFS      SSHFS  `yaml:"sshfs,omitempty" json:"sshfs,omitempty"`
+}
+
+type SSHFS struct {
+	Cache          *bool `yaml:"cache,omitempty" json:"cache,omitempty"`
+	FollowSymlinks *bool `yaml:"followSymlinks,omitempty" json:"followSymlinks,omitempty"`
 }
 
 type SSH struct {
@@ -65,16 +99,61 @@ type Video struct {
 	Display string `yaml:"display,omitempty" json:"display,omitempty"`
 }
 
+type VMOpts struct {
+	QEMU QEMUOpts `yaml:"qemu,omitempty" json:"qemu,omitempty"`
+}
+
+type QEMUOpts struct {
+	// Accel is the QEMU accelerator.
+	// Empty means the accelerator is detected automatically.
+	Accel Accel `yaml:"accel,omitempty" json:"accel,omitempty"`
+	// ExtraArgs are appended to the QEMU command line.
+	// The options that can be specified only once, such as `-machine`, take precedence over the default values.
+	// The options that Lima needs to control, such as `-qmp` and `-serial`, cannot be specified.
+	ExtraArgs []string `yaml:"extraArgs,omitempty" json:"extraArgs,omitempty"`
+}
+
+type Accel = string
+
+const (
+	AccelHVF  Accel = "hvf"
+	AccelKVM  Accel = "kvm"
+	AccelNVMM Accel = "nvmm"
+	AccelWHPX Accel = "whpx"
+	AccelTCG  Accel = "tcg"
+)
+
 type ProvisionMode = string
 
 const (
-	ProvisionModeSystem ProvisionMode = "system"
-	ProvisionModeUser   ProvisionMode = "user"
+	ProvisionModeSystem  ProvisionMode = "system"
+	ProvisionModeUser    ProvisionMode = "user"
+	ProvisionModeData    ProvisionMode = "data"
+	ProvisionModeAnsible ProvisionMode = "ansible"
+)
+
+type ProvisionWhen = string
+
+const (
+	ProvisionWhenAlways ProvisionWhen = "always"
+	ProvisionWhenOnce   ProvisionWhen = "once"
 )
 
 type Provision struct {
-	Mode   ProvisionMode `yaml:"mode" json:"mode"` // default: "system"
-	Script string        `yaml:"script" json:"script"`
+	Mode   ProvisionMode `yaml:"mode" json:"mode"`                       // default: "system"
+	Name   string        `yaml:"name,omitempty" json:"name,omitempty"`   // default: "provision-N"
+	When   ProvisionWhen `yaml:"when,omitempty" json:"when,omitempty"`   // default: "always"
+	After  []string      `yaml:"after,omitempty" json:"after,omitempty"` // names of the provisions to be executed before this one
+	Script string        `yaml:"script,omitempty" json:"script,omitempty"`
+	// File is a local file or a URL of the script (or the content for "data"), used instead of Script (or Content)
+	File *File `yaml:"file,omitempty" json:"file,omitempty"`
+	// The fields below are only used for "data"
+	Path        string `yaml:"path,omitempty" json:"path,omitempty"`
+	Content     string `yaml:"content,omitempty" json:"content,omitempty"`
+	Owner       string `yaml:"owner,omitempty" json:"owner,omitempty"`             // default: "root:root"
+	Permissions string `yaml:"permissions,omitempty" json:"permissions,omitempty"` // default: "644"
+	// Playbook is only used for "ansible". A relative path is resolved against the instance directory (the directory of lima.yaml).
+	Playbook string `yaml:"playbook,omitempty" json:"playbook,omitempty"`
 }
 
 type Containerd struct {
@@ -87,19 +166,27 @@ type ProbeMode = string
 
 const (
 	ProbeModeReadiness ProbeMode = "readiness"
+	ProbeModeLiveness  ProbeMode = "liveness"
 )
 
 type Probe struct {
-	Mode        ProbeMode // default: "readiness"
+	Mode        ProbeMode // default: "readiness", "liveness" is executed periodically while the instance is running
 	Description string
 	Script      string
 	Hint        string
+	// Timeout is the duration of waiting for a readiness probe to succeed (default: bootTimeout),
+	// or the duration of each execution of a liveness probe (default: "30s").
+	Timeout string
+	// Interval is the duration between the retries of a readiness probe (default: "10s"),
+	// or between the executions of a liveness probe (default: "30s").
+	Interval string
 }
 
 type Proto = string
 
 const (
 	TCP Proto = "tcp"
+	UDP Proto = "udp"
 )
 
 type PortForward struct {
@@ -0,0 +1,57 @@
+package limayaml
+
+import (
+	"fmt"
+)
+
+// ProvisionOrder returns the indices of the provisions in the order of execution.
+// A provision is executed after the provisions listed in its `after` field.
+// Otherwise the order in the YAML is preserved.
+func ProvisionOrder(provision []Provision) ([]int, error) {
+	byName := make(map[string]int, len(provision))
+	for i, p := range provision {
+		if j, ok := byName[p.Name]; ok {
+			return nil, fmt.Errorf("field `provision[%d].name` must be unique, but `provision[%d].name` is also %q", i, j, p.Name)
+		}
+		byName[p.Name] = i
+	}
+	for i, p := range provision {
+		for _, after := range p.After {
+			if _, ok := byName[after]; !ok {
+				return nil, fmt.Errorf("field `provision[%d].after` refers to an unknown provision %q", i, after)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(provision))
+	order := make([]int, 0, len(provision))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("field `provision[%d].after` has a circular dependency on %q", i, provision[i].Name)
+		}
+		state[i] = visiting
+		for _, after := range provision[i].After {
+			if err := visit(byName[after]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+	for i := range provision {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
@@ -0,0 +1,48 @@
+package limayaml
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProvisionOrder(t *testing.T) {
+	provision := []Provision{
+		{Name: "a", After: []string{"c"}},
+		{Name: "b"},
+		{Name: "c", After: []string{"b"}},
+		{Name: "d"},
+	}
+	order, err := ProvisionOrder(provision)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []int{1, 2, 0, 3}, order)
+
+	provision[1].After = []string{"a"}
+	_, err = ProvisionOrder(provision)
+	assert.ErrorContains(t, err, "circular dependency")
+
+	provision[1].After = []string{"x"}
+	_, err = ProvisionOrder(provision)
+	assert.ErrorContains(t, err, "unknown provision")
+
+	provision[1].Name = "a"
+	_, err = ProvisionOrder(provision)
+	assert.ErrorContains(t, err, "must be unique")
+}
+
+func TestPlaybookPath(t *testing.T) {
+	y, err := Load([]byte(`
+images: [{location: /foo.qcow2}]
+provision:
+- mode: ansible
+  playbook: site.yml
+- mode: ansible
+  playbook: /abs/site.yml
+- mode: ansible
+  playbook: ~/site.yml
+`), "/lima/default/lima.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, "/lima/default/site.yml", y.Provision[0].Playbook)
+	assert.Equal(t, "/abs/site.yml", y.Provision[1].Playbook)
+	assert.Equal(t, "~/site.yml", y.Provision[2].Playbook)
+}
@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"errors"
 
+	"github.com/containerd/containerd/identifiers"
 	"github.com/docker/go-units"
 	"github.com/lima-vm/lima/pkg/localpathutil"
 	"github.com/lima-vm/lima/pkg/networks"
@@ -54,6 +58,21 @@ func Validate(y LimaYAML, warn bool) error {
 		return errors.New("field `cpus` must be set")
 	}
 
+	for arch, cpuType := range y.CPUType {
+		switch arch {
+		case X8664, AARCH64:
+		default:
+			return fmt.Errorf("field `cpuType` must have keys %q or %q, got %q", X8664, AARCH64, arch)
+		}
+		// Empty value means the default CPU type
+		if strings.ContainsAny(cpuType, " \t\n") {
+			return fmt.Errorf("field `cpuType.%s` must not contain whitespaces, got %q", arch, cpuType)
+		}
+		if strings.Split(cpuType, ",")[0] == "host" && arch != resolveArch("") {
+			return fmt.Errorf("field `cpuType.%s` must not be %q, as %q is not the native architecture", arch, cpuType, arch)
+		}
+	}
+
 	if _, err := units.RAMInBytes(y.Memory); err != nil {
 		return fmt.Errorf("field `memory` has an invalid value: %w", err)
 	}
@@ -69,6 +88,7 @@ func Validate(y LimaYAML, warn bool) error {
 	// reservedHome is the home directory defined in "cidata.iso:/user-data"
 	reservedHome := fmt.Sprintf("/home/%s.linux", u.Username)
 
+	mountPoints := make(map[string]int, len(y.Mounts))
 	for i, f := range y.Mounts {
 		if !filepath.IsAbs(f.Location) && !strings.HasPrefix(f.Location, "~") {
 			return fmt.Errorf("field `mounts[%d].location` must be an absolute path, got %q",
@@ -93,6 +113,68 @@ func Validate(y LimaYAML, warn bool) error {
 		} else if !st.IsDir() {
 			return fmt.Errorf("field `mounts[%d].location` refers to a non-directory path: %q: %w", i, f.Location, err)
 		}
+
+		// mountPoint is a path in the (Linux) guest
+		if !path.IsAbs(f.MountPoint) {
+			return fmt.Errorf("field `mounts[%d].mountPoint` must be an absolute path, got %q", i, f.MountPoint)
+		}
+		switch path.Clean(f.MountPoint) {
+		case "/", "/bin", "/dev", "/etc", "/home", "/opt", "/sbin", "/tmp", "/usr", "/var":
+			return fmt.Errorf("field `mounts[%d].mountPoint` must not be a system path such as /etc or /usr", i)
+		case reservedHome:
+			return fmt.Errorf("field `mounts[%d].mountPoint` is internally reserved", i)
+		}
+		if j, ok := mountPoints[path.Clean(f.MountPoint)]; ok {
+			return fmt.Errorf("field `mounts[%d].mountPoint` must not be the same as `mounts[%d].mountPoint`: %q", i, j, f.MountPoint)
+		}
+		mountPoints[path.Clean(f.MountPoint)] = i
+	}
+
+	diskNames := make(map[string]int, len(y.AdditionalDisks))
+	diskMountPoints := make(map[string]int, len(y.AdditionalDisks))
+	for i, d := range y.AdditionalDisks {
+		if err := identifiers.Validate(d.Name); err != nil {
+			return fmt.Errorf("field `additionalDisks[%d].name` is invalid: %w", i, err)
+		}
+		if j, ok := diskNames[d.Name]; ok {
+			return fmt.Errorf("field `additionalDisks[%d].name` must not be the same as `additionalDisks[%d].name`: %q", i, j, d.Name)
+		}
+		diskNames[d.Name] = i
+		if !path.IsAbs(d.MountPoint) {
+			return fmt.Errorf("field `additionalDisks[%d].mountPoint` must be an absolute path, got %q", i, d.MountPoint)
+		}
+		switch path.Clean(d.MountPoint) {
+		case "/", "/bin", "/dev", "/etc", "/home", "/opt", "/sbin", "/tmp", "/usr", "/var":
+			return fmt.Errorf("field `additionalDisks[%d].mountPoint` must not be a system path such as /etc or /usr", i)
+		case reservedHome:
+			return fmt.Errorf("field `additionalDisks[%d].mountPoint` is internally reserved", i)
+		}
+		if j, ok := mountPoints[path.Clean(d.MountPoint)]; ok {
+			return fmt.Errorf("field `additionalDisks[%d].mountPoint` must not be the same as `mounts[%d].mountPoint`: %q", i, j, d.MountPoint)
+		}
+		if j, ok := diskMountPoints[path.Clean(d.MountPoint)]; ok {
+			return fmt.Errorf("field `additionalDisks[%d].mountPoint` must not be the same as `additionalDisks[%d].mountPoint`: %q", i, j, d.MountPoint)
+		}
+		diskMountPoints[path.Clean(d.MountPoint)] = i
+		switch d.FSType {
+		case EXT4, XFS:
+		default:
+			return fmt.Errorf("field `additionalDisks[%d].fsType` must be %q or %q, got %q", i, EXT4, XFS, d.FSType)
+		}
+	}
+
+	if y.WorkDir != "" && !path.IsAbs(y.WorkDir) {
+		return fmt.Errorf("field `workDir` must be an absolute path in the guest, got %q", y.WorkDir)
+	}
+
+	switch y.MountType {
+	case REVSSHFS, NINEP:
+	default:
+		return fmt.Errorf("field `mountType` must be %q or %q, got %q", REVSSHFS, NINEP, y.MountType)
+	}
+	if y.MountType == NINEP && runtime.GOOS == "darwin" {
+		// QEMU does not support virtfs for macOS hosts (as of QEMU 6.1)
+		return fmt.Errorf("field `mountType` must not be %q on macOS hosts, use %q instead", NINEP, REVSSHFS)
 	}
 
 	if y.SSH.LocalPort != 0 {
@@ -103,92 +185,128 @@ func Validate(y LimaYAML, warn bool) error {
 
 	// y.Firmware.LegacyBIOS is ignored for aarch64, but not a fatal error.
 
-	for i, p := range y.Provision {
-		switch p.Mode {
-		case ProvisionModeSystem, ProvisionModeUser:
-		default:
-			return fmt.Errorf("field `provision[%d].mode` must be either %q or %q",
-				i, ProvisionModeSystem, ProvisionModeUser)
-		}
-	}
-	needsContainerdArchives := (y.Containerd.User != nil && *y.Containerd.User) || (y.Containerd.System != nil && *y.Containerd.System)
-	if needsContainerdArchives && len(y.Containerd.Archives) == 0 {
-		return fmt.Errorf("field `containerd.archives` must be provided")
+	switch y.VMOpts.QEMU.Accel {
+	case "", AccelHVF, AccelKVM, AccelNVMM, AccelWHPX, AccelTCG:
+	default:
+		return fmt.Errorf("field `vmOpts.qemu.accel` must be %q, %q, %q, %q, or %q, got %q",
+			AccelHVF, AccelKVM, AccelNVMM, AccelWHPX, AccelTCG, y.VMOpts.QEMU.Accel)
 	}
-	for i, p := range y.Probes {
-		switch p.Mode {
-		case ProbeModeReadiness:
-		default:
-			return fmt.Errorf("field `probe[%d].mode` can only be %q",
-				i, ProbeModeReadiness)
+	// Each element must be an option, or the single value that follows an option
+	expectOption := true
+	for i, arg := range y.VMOpts.QEMU.ExtraArgs {
+		if arg == "" {
+			return fmt.Errorf("field `vmOpts.qemu.extraArgs[%d]` must not be empty", i)
+		}
+		if strings.HasPrefix(arg, "-") {
+			switch arg {
+			case "-name", "-pidfile", "-serial", "-parallel", "-vga", "-qmp", "-incoming":
+				return fmt.Errorf("field `vmOpts.qemu.extraArgs[%d]` must not be %q, which is reserved by Lima", i, arg)
+			}
+			expectOption = false
+			continue
+		}
+		if expectOption {
+			return fmt.Errorf("field `vmOpts.qemu.extraArgs[%d]` must be an option starting with \"-\", got %q", i, arg)
 		}
+		expectOption = true
 	}
-	for i, rule := range y.PortForwards {
-		field := fmt.Sprintf("portForwards[%d]", i)
-		if rule.GuestPort != 0 {
-			if rule.GuestSocket != "" {
-				return fmt.Errorf("field `%s.guestPort` must be 0 when field `%s.guestSocket` is set", field, field)
+
+	for i, p := range y.Provision {
+		switch p.Mode {
+		case ProvisionModeSystem, ProvisionModeUser:
+			if (p.Script == "") == (p.File == nil) {
+				return fmt.Errorf("field `provision[%d]` must have either `script` or `file`", i)
 			}
-			if rule.GuestPort != rule.GuestPortRange[0] {
-				return fmt.Errorf("field `%s.guestPort` must match field `%s.guestPortRange[0]`", field, field)
+			if p.Path != "" || p.Content != "" {
+				return fmt.Errorf("field `provision[%d]` must not have `path` or `content` for mode %q", i, p.Mode)
 			}
-			// redundant validation to make sure the error contains the correct field name
-			if err := validatePort(field+".guestPort", rule.GuestPort); err != nil {
-				return err
+		case ProvisionModeData:
+			if (p.Content == "") == (p.File == nil) {
+				return fmt.Errorf("field `provision[%d]` must have either `content` or `file`", i)
 			}
-		}
-		if rule.HostPort != 0 {
-			if rule.HostSocket != "" {
-				return fmt.Errorf("field `%s.hostPort` must be 0 when field `%s.hostSocket` is set", field, field)
+			if p.Script != "" {
+				return fmt.Errorf("field `provision[%d]` must not have `script` for mode %q", i, p.Mode)
 			}
-			if rule.HostPort != rule.HostPortRange[0] {
-				return fmt.Errorf("field `%s.hostPort` must match field `%s.hostPortRange[0]`", field, field)
+			if !path.IsAbs(p.Path) {
+				return fmt.Errorf("field `provision[%d].path` must be an absolute path in the guest, got %q", i, p.Path)
 			}
-			// redundant validation to make sure the error contains the correct field name
-			if err := validatePort(field+".hostPort", rule.HostPort); err != nil {
-				return err
+			if p.Owner == "" {
+				return fmt.Errorf("field `provision[%d].owner` must be set", i)
 			}
-		}
-		for j := 0; j < 2; j++ {
-			if err := validatePort(fmt.Sprintf("%s.guestPortRange[%d]", field, j), rule.GuestPortRange[j]); err != nil {
-				return err
+			if perm, err := strconv.ParseUint(p.Permissions, 8, 32); err != nil || perm > 07777 {
+				return fmt.Errorf("field `provision[%d].permissions` must be an octal number such as \"644\", got %q", i, p.Permissions)
 			}
-			if err := validatePort(fmt.Sprintf("%s.hostPortRange[%d]", field, j), rule.HostPortRange[j]); err != nil {
-				return err
+		case ProvisionModeAnsible:
+			if p.Playbook == "" {
+				return fmt.Errorf("field `provision[%d].playbook` must be set for mode %q", i, p.Mode)
+			}
+			if p.Script != "" || p.File != nil || p.Path != "" || p.Content != "" {
+				return fmt.Errorf("field `provision[%d]` must not have `script`, `file`, `path`, or `content` for mode %q", i, p.Mode)
 			}
+			if p.When != ProvisionWhenAlways {
+				return fmt.Errorf("field `provision[%d].when` must be %q for mode %q", i, ProvisionWhenAlways, p.Mode)
+			}
+		default:
+			return fmt.Errorf("field `provision[%d].mode` must be %q, %q, %q, or %q",
+				i, ProvisionModeSystem, ProvisionModeUser, ProvisionModeData, ProvisionModeAnsible)
 		}
-		if rule.GuestPortRange[0] > rule.GuestPortRange[1] {
-			return fmt.Errorf("field `%s.guestPortRange[1]` must be greater than or equal to field `%s.guestPortRange[0]`", field, field)
+		if p.Mode != ProvisionModeAnsible && p.Playbook != "" {
+			return fmt.Errorf("field `provision[%d]` must not have `playbook` for mode %q", i, p.Mode)
 		}
-		if rule.HostPortRange[0] > rule.HostPortRange[1] {
-			return fmt.Errorf("field `%s.hostPortRange[1]` must be greater than or equal to field `%s.hostPortRange[0]`", field, field)
+		if p.File != nil {
+			if err := validateProvisionFile(i, *p.File); err != nil {
+				return err
+			}
 		}
-		if rule.GuestPortRange[1]-rule.GuestPortRange[0] != rule.HostPortRange[1]-rule.HostPortRange[0] {
-			return fmt.Errorf("field `%s.hostPortRange` must specify the same number of ports as field `%s.guestPortRange`", field, field)
+		if err := identifiers.Validate(p.Name); err != nil {
+			return fmt.Errorf("field `provision[%d].name` is invalid: %w", i, err)
 		}
-		if rule.GuestSocket != "" {
-			if !filepath.IsAbs(rule.GuestSocket) {
-				return fmt.Errorf("field `%s.guestSocket` must be an absolute path", field)
-			}
-			if rule.HostSocket == "" && rule.HostPortRange[1]-rule.HostPortRange[0] > 0 {
-				return fmt.Errorf("field `%s.guestSocket` can only be mapped to a single port or socket. not a range", field)
-			}
+		switch p.When {
+		case ProvisionWhenAlways, ProvisionWhenOnce:
+		default:
+			return fmt.Errorf("field `provision[%d].when` must be either %q or %q",
+				i, ProvisionWhenAlways, ProvisionWhenOnce)
 		}
-		if rule.HostSocket != "" {
-			if !filepath.IsAbs(rule.HostSocket) {
-				// should be unreachable because FillDefault() will prepend the instance directory to relative names
-				return fmt.Errorf("field `%s.hostSocket` must be an absolute path", field)
-			}
-			if rule.GuestSocket == "" && rule.GuestPortRange[1]-rule.GuestPortRange[0] > 0 {
-				return fmt.Errorf("field `%s.hostSocket` can only be mapped from a single port or socket. not a range", field)
+	}
+	if _, err := ProvisionOrder(y.Provision); err != nil {
+		return err
+	}
+	// "ansible" is executed on the host after the guest has booted, so it cannot be ordered with the other modes
+	provisionModes := make(map[string]ProvisionMode, len(y.Provision))
+	for _, p := range y.Provision {
+		provisionModes[p.Name] = p.Mode
+	}
+	for i, p := range y.Provision {
+		for _, after := range p.After {
+			if (p.Mode == ProvisionModeAnsible) != (provisionModes[after] == ProvisionModeAnsible) {
+				return fmt.Errorf("field `provision[%d].after` must not mix mode %q with the other modes, got %q", i, ProvisionModeAnsible, after)
 			}
 		}
-		if len(rule.HostSocket) >= osutil.UnixPathMax {
-			return fmt.Errorf("field `%s.hostSocket` must be less than UNIX_PATH_MAX=%d characers, but is %d",
-				field, osutil.UnixPathMax, len(rule.HostSocket))
+	}
+	needsContainerdArchives := (y.Containerd.User != nil && *y.Containerd.User) || (y.Containerd.System != nil && *y.Containerd.System)
+	if needsContainerdArchives && len(y.Containerd.Archives) == 0 {
+		return fmt.Errorf("field `containerd.archives` must be provided")
+	}
+	if err := validateDuration("bootTimeout", y.BootTimeout); err != nil {
+		return err
+	}
+	for i, p := range y.Probes {
+		switch p.Mode {
+		case ProbeModeReadiness, ProbeModeLiveness:
+		default:
+			return fmt.Errorf("field `probe[%d].mode` must be either %q or %q",
+				i, ProbeModeReadiness, ProbeModeLiveness)
+		}
+		if err := validateDuration(fmt.Sprintf("probe[%d].timeout", i), p.Timeout); err != nil {
+			return err
 		}
-		if rule.Proto != TCP {
-			return fmt.Errorf("field `%s.proto` must be %q", field, TCP)
+		if err := validateDuration(fmt.Sprintf("probe[%d].interval", i), p.Interval); err != nil {
+			return err
+		}
+	}
+	for i, rule := range y.PortForwards {
+		if err := ValidatePortForward(fmt.Sprintf("portForwards[%d]", i), rule); err != nil {
+			return err
 		}
 		// Not validating that the various GuestPortRanges and HostPortRanges are not overlapping. Rules will be
 		// processed sequentially and the first matching rule for a guest port determines forwarding behavior.
@@ -204,6 +322,117 @@ func Validate(y LimaYAML, warn bool) error {
 	return nil
 }
 
+// ValidatePortForward validates the port forwarding rule that has been filled with FillPortForwardDefaults.
+// field is used in the error messages, e.g., "portForwards[0]".
+func ValidatePortForward(field string, rule PortForward) error {
+	if rule.GuestPort != 0 {
+		if rule.GuestSocket != "" {
+			return fmt.Errorf("field `%s.guestPort` must be 0 when field `%s.guestSocket` is set", field, field)
+		}
+		if rule.GuestPort != rule.GuestPortRange[0] {
+			return fmt.Errorf("field `%s.guestPort` must match field `%s.guestPortRange[0]`", field, field)
+		}
+		// redundant validation to make sure the error contains the correct field name
+		if err := validatePort(field+".guestPort", rule.GuestPort); err != nil {
+			return err
+		}
+	}
+	if rule.HostPort != 0 {
+		if rule.HostSocket != "" {
+			return fmt.Errorf("field `%s.hostPort` must be 0 when field `%s.hostSocket` is set", field, field)
+		}
+		if rule.HostPort != rule.HostPortRange[0] {
+			return fmt.Errorf("field `%s.hostPort` must match field `%s.hostPortRange[0]`", field, field)
+		}
+		// redundant validation to make sure the error contains the correct field name
+		if err := validatePort(field+".hostPort", rule.HostPort); err != nil {
+			return err
+		}
+	}
+	for j := 0; j < 2; j++ {
+		if err := validatePort(fmt.Sprintf("%s.guestPortRange[%d]", field, j), rule.GuestPortRange[j]); err != nil {
+			return err
+		}
+		if err := validatePort(fmt.Sprintf("%s.hostPortRange[%d]", field, j), rule.HostPortRange[j]); err != nil {
+			return err
+		}
+	}
+	if rule.GuestPortRange[0] > rule.GuestPortRange[1] {
+		return fmt.Errorf("field `%s.guestPortRange[1]` must be greater than or equal to field `%s.guestPortRange[0]`", field, field)
+	}
+	if rule.HostPortRange[0] > rule.HostPortRange[1] {
+		return fmt.Errorf("field `%s.hostPortRange[1]` must be greater than or equal to field `%s.hostPortRange[0]`", field, field)
+	}
+	if rule.GuestPortRange[1]-rule.GuestPortRange[0] != rule.HostPortRange[1]-rule.HostPortRange[0] {
+		return fmt.Errorf("field `%s.hostPortRange` must specify the same number of ports as field `%s.guestPortRange`", field, field)
+	}
+	if rule.GuestSocket != "" {
+		if !filepath.IsAbs(rule.GuestSocket) {
+			return fmt.Errorf("field `%s.guestSocket` must be an absolute path", field)
+		}
+		if rule.HostSocket == "" && rule.HostPortRange[1]-rule.HostPortRange[0] > 0 {
+			return fmt.Errorf("field `%s.guestSocket` can only be mapped to a single port or socket. not a range", field)
+		}
+	}
+	if rule.HostSocket != "" {
+		if !filepath.IsAbs(rule.HostSocket) {
+			// should be unreachable because FillDefault() will prepend the instance directory to relative names
+			return fmt.Errorf("field `%s.hostSocket` must be an absolute path", field)
+		}
+		if rule.GuestSocket == "" && rule.GuestPortRange[1]-rule.GuestPortRange[0] > 0 {
+			return fmt.Errorf("field `%s.hostSocket` can only be mapped from a single port or socket. not a range", field)
+		}
+	}
+	if len(rule.HostSocket) >= osutil.UnixPathMax {
+		return fmt.Errorf("field `%s.hostSocket` must be less than UNIX_PATH_MAX=%d characers, but is %d",
+			field, osutil.UnixPathMax, len(rule.HostSocket))
+	}
+	switch rule.Proto {
+	case TCP:
+	case UDP:
+		if rule.GuestSocket != "" || rule.HostSocket != "" {
+			return fmt.Errorf("field `%s.proto` must be %q when field `%s.guestSocket` or field `%s.hostSocket` is set", field, TCP, field, field)
+		}
+	default:
+		return fmt.Errorf("field `%s.proto` must be %q or %q", field, TCP, UDP)
+	}
+	return nil
+}
+
+func validateDuration(field, s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("field `%s` must be a duration such as \"10m\", got %q: %w", field, s, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("field `%s` must be positive, got %q", field, s)
+	}
+	return nil
+}
+
+func validateProvisionFile(i int, f File) error {
+	if f.Location == "" {
+		return fmt.Errorf("field `provision[%d].file.location` must be set", i)
+	}
+	if !strings.Contains(f.Location, "://") {
+		if !filepath.IsAbs(f.Location) && !strings.HasPrefix(f.Location, "~") {
+			return fmt.Errorf("field `provision[%d].file.location` must be an absolute path or a URL, got %q", i, f.Location)
+		}
+		if _, err := localpathutil.Expand(f.Location); err != nil {
+			return fmt.Errorf("field `provision[%d].file.location` refers to an invalid local file path: %q: %w", i, f.Location, err)
+		}
+	}
+	if f.Digest != "" {
+		if !f.Digest.Algorithm().Available() {
+			return fmt.Errorf("field `provision[%d].file.digest` refers to an unavailable digest algorithm", i)
+		}
+		if err := f.Digest.Validate(); err != nil {
+			return fmt.Errorf("field `provision[%d].file.digest` is invalid: %s: %w", i, f.Digest.String(), err)
+		}
+	}
+	return nil
+}
+
 func validateNetwork(y LimaYAML, warn bool) error {
 	if len(y.Network.VDEDeprecated) > 0 {
 		if y.Network.migrated {
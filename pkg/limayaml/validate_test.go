@@ -0,0 +1,43 @@
+package limayaml
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidateExtraArgs(t *testing.T) {
+	y, err := Load([]byte("images: [{location: /foo.qcow2}]"), "lima.yaml")
+	assert.NilError(t, err)
+	assert.NilError(t, Validate(*y, false))
+
+	y.VMOpts.QEMU.ExtraArgs = []string{"-machine", "q35,accel=tcg", "-s", "-device", "virtio-balloon"}
+	assert.NilError(t, Validate(*y, false))
+
+	y.VMOpts.QEMU.ExtraArgs = []string{"-machine", "a", "b"}
+	assert.ErrorContains(t, Validate(*y, false), "field `vmOpts.qemu.extraArgs[2]` must be an option")
+
+	y.VMOpts.QEMU.ExtraArgs = []string{"q35"}
+	assert.ErrorContains(t, Validate(*y, false), "field `vmOpts.qemu.extraArgs[0]` must be an option")
+
+	y.VMOpts.QEMU.ExtraArgs = []string{"-s", "-qmp", "stdio"}
+	assert.ErrorContains(t, Validate(*y, false), "field `vmOpts.qemu.extraArgs[1]` must not be \"-qmp\"")
+}
+
+func TestValidateCPUType(t *testing.T) {
+	y, err := Load([]byte("images: [{location: /foo.qcow2}]"), "lima.yaml")
+	assert.NilError(t, err)
+	nativeArch, foreignArch := X8664, AARCH64
+	if resolveArch("") == AARCH64 {
+		nativeArch, foreignArch = AARCH64, X8664
+	}
+
+	y.CPUType = map[Arch]string{nativeArch: "host", foreignArch: "max"}
+	assert.NilError(t, Validate(*y, false))
+
+	y.CPUType = map[Arch]string{foreignArch: "host,+avx2"}
+	assert.ErrorContains(t, Validate(*y, false), "field `cpuType."+foreignArch+"` must not be \"host,+avx2\"")
+
+	y.CPUType = map[Arch]string{nativeArch: "Haswell v4"}
+	assert.ErrorContains(t, Validate(*y, false), "must not contain whitespaces")
+}
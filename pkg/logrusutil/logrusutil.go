@@ -13,15 +13,17 @@ const epsilon = 1 * time.Second
 // PropagateJSON propagates JSONFormatter lines.
 //
 // PanicLevel and FatalLevel are converted to ErrorLevel.
+// The time of the line is preserved, so that the lines can be replayed with the original timestamps.
 func PropagateJSON(logger *logrus.Logger, jsonLine []byte, header string, begin time.Time) {
 	if strings.TrimSpace(string(jsonLine)) == "" {
 		return
 	}
 
 	var (
-		lv  logrus.Level
-		j   JSON
-		err error
+		entry *logrus.Entry
+		lv    logrus.Level
+		j     JSON
+		err   error
 	)
 	if err := json.Unmarshal(jsonLine, &j); err != nil {
 		goto fallback
@@ -33,24 +35,28 @@ func PropagateJSON(logger *logrus.Logger, jsonLine []byte, header string, begin
 	if err != nil {
 		goto fallback
 	}
+	entry = logrus.NewEntry(logger)
+	if !j.Time.IsZero() {
+		entry = entry.WithTime(j.Time)
+	}
 	switch lv {
 	case logrus.PanicLevel, logrus.FatalLevel:
-		logger.WithField("level", lv).Error(header + j.Msg)
+		entry.WithField("level", lv).Error(header + j.Msg)
 	case logrus.ErrorLevel:
-		logger.Error(header + j.Msg)
+		entry.Error(header + j.Msg)
 	case logrus.WarnLevel:
-		logger.Warn(header + j.Msg)
+		entry.Warn(header + j.Msg)
 	case logrus.InfoLevel:
-		logger.Info(header + j.Msg)
+		entry.Info(header + j.Msg)
 	case logrus.DebugLevel:
-		logger.Debug(header + j.Msg)
+		entry.Debug(header + j.Msg)
 	case logrus.TraceLevel:
-		logger.Trace(header + j.Msg)
+		entry.Trace(header + j.Msg)
 	}
 	return
 
 fallback:
-	logrus.Info(header + string(jsonLine))
+	logger.Info(header + string(jsonLine))
 }
 
 // JSON is the type used in logrus.JSONFormatter
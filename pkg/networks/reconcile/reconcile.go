@@ -38,7 +38,9 @@ func Reconcile(ctx context.Context, newInst string) error {
 			return err
 		}
 		// newInst is about to be started, so its networks should be running
-		if instance.Status != store.StatusRunning && instName != newInst {
+		// A suspended instance still has the QEMU process, unless its state is saved to disk
+		running := instance.Status == store.StatusRunning || (instance.Status == store.StatusSuspended && instance.QemuPID > 0)
+		if !running && instName != newInst {
 			continue
 		}
 		for _, nw := range instance.Networks {
@@ -11,12 +11,37 @@ import (
 
 // Info corresponds to the output of `qemu-img info --output=json FILE`
 type Info struct {
-	Format string `json:"format,omitempty"` // since QEMU 1.3
+	Format          string     `json:"format,omitempty"`           // since QEMU 1.3
+	VSize           int64      `json:"virtual-size,omitempty"`     // since QEMU 1.3
+	BackingFilename string     `json:"backing-filename,omitempty"` // since QEMU 1.3
+	Snapshots       []Snapshot `json:"snapshots,omitempty"`        // since QEMU 1.3
+}
+
+// Snapshot corresponds to an element of the "snapshots" array in the output of `qemu-img info --output=json FILE`
+type Snapshot struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	VMStateSize int64  `json:"vm-state-size"`
+	DateSec     int64  `json:"date-sec"`
+	DateNsec    int64  `json:"date-nsec"`
+	VMClockSec  int64  `json:"vm-clock-sec"`
+	VMClockNsec int64  `json:"vm-clock-nsec"`
 }
 
 func GetInfo(f string) (*Info, error) {
+	return getInfo(f)
+}
+
+// GetInfoShared is similar to GetInfo, but allows inspecting the image while QEMU is running.
+// GetInfoShared needs QEMU 2.10 or later, for `--force-share`.
+func GetInfoShared(f string) (*Info, error) {
+	return getInfo(f, "--force-share")
+}
+
+func getInfo(f string, opts ...string) (*Info, error) {
 	var stdout, stderr bytes.Buffer
-	cmd := exec.Command("qemu-img", "info", "--output=json", f)
+	args := append([]string{"info", "--output=json"}, opts...)
+	cmd := exec.Command("qemu-img", append(args, f)...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
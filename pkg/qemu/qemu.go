@@ -12,13 +12,17 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/alessio/shellescape"
+	continuityfs "github.com/containerd/continuity/fs"
 	"github.com/docker/go-units"
 	"github.com/lima-vm/lima/pkg/downloader"
 	"github.com/lima-vm/lima/pkg/iso9660util"
 	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/localpathutil"
 	"github.com/lima-vm/lima/pkg/networks"
 	qemu "github.com/lima-vm/lima/pkg/qemu/const"
 	"github.com/lima-vm/lima/pkg/qemu/imgutil"
+	"github.com/lima-vm/lima/pkg/store/dirnames"
 	"github.com/lima-vm/lima/pkg/store/filenames"
 	"github.com/mattn/go-shellwords"
 	"github.com/sirupsen/logrus"
@@ -97,6 +101,91 @@ func EnsureDisk(cfg Config) error {
 	return nil
 }
 
+// CreateDataDisk creates an empty qcow2 disk of size bytes, for `additionalDisks`.
+func CreateDataDisk(dir string, size int64) error {
+	dataDisk := filepath.Join(dir, filenames.DataDisk)
+	if _, err := os.Stat(dataDisk); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		// datadisk already exists
+		return err
+	}
+	cmd := exec.Command("qemu-img", "create", "-f", "qcow2", dataDisk, strconv.FormatInt(size, 10))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run %v: %q: %w", cmd.Args, string(out), err)
+	}
+	return nil
+}
+
+// ResizeDisk grows the diffdisk to size bytes.
+// Shrinking is not supported, as it would corrupt the filesystem.
+// The root filesystem is grown by cloud-init (growpart) on the next boot.
+func ResizeDisk(cfg Config, size int64) error {
+	diffDisk := filepath.Join(cfg.InstanceDir, filenames.DiffDisk)
+	diffDiskInfo, err := imgutil.GetInfo(diffDisk)
+	if err != nil {
+		return err
+	}
+	if size < diffDiskInfo.VSize {
+		return fmt.Errorf("shrinking the disk of instance %q from %s to %s is not supported",
+			cfg.Name, units.BytesSize(float64(diffDiskInfo.VSize)), units.BytesSize(float64(size)))
+	}
+	if size == diffDiskInfo.VSize {
+		return nil
+	}
+	logrus.Infof("Resizing the disk of instance %q from %s to %s",
+		cfg.Name, units.BytesSize(float64(diffDiskInfo.VSize)), units.BytesSize(float64(size)))
+	cmd := exec.Command("qemu-img", "resize", diffDisk, strconv.FormatInt(size, 10))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run %v: %q: %w", cmd.Args, string(out), err)
+	}
+	return nil
+}
+
+// CloneDisk copies the basedisk and the diffdisk of src into dst.
+// The backing file of the copied diffdisk is rewritten to refer to the copied basedisk.
+//
+// The diffdisk is fully copied rather than being used as a backing file of a new overlay,
+// because the overlay would be corrupted when src is started again.
+func CloneDisk(src, dst Config) error {
+	for _, f := range []string{filenames.BaseDisk, filenames.DiffDisk} {
+		srcPath := filepath.Join(src.InstanceDir, f)
+		if _, err := os.Stat(srcPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		dstPath := filepath.Join(dst.InstanceDir, f)
+		logrus.Infof("Copying %q to %q", srcPath, dstPath)
+		if err := continuityfs.CopyFile(dstPath, srcPath); err != nil {
+			return fmt.Errorf("failed to copy %q to %q: %w", srcPath, dstPath, err)
+		}
+	}
+
+	diffDisk := filepath.Join(dst.InstanceDir, filenames.DiffDisk)
+	if _, err := os.Stat(diffDisk); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	diffDiskInfo, err := imgutil.GetInfo(diffDisk)
+	if err != nil {
+		return err
+	}
+	if diffDiskInfo.BackingFilename == "" {
+		// the basedisk is an ISO9660 image
+		return nil
+	}
+	baseDisk := filepath.Join(dst.InstanceDir, filenames.BaseDisk)
+	baseDiskFormat, err := imgutil.DetectFormat(baseDisk)
+	if err != nil {
+		return err
+	}
+	// "-u" (unsafe) only rewrites the header, as the content of the new backing file is identical to the old one
+	cmd := exec.Command("qemu-img", "rebase", "-u", "-F", baseDiskFormat, "-b", baseDisk, diffDisk)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to run %v: %q: %w", cmd.Args, string(out), err)
+	}
+	return nil
+}
+
 func argValue(args []string, key string) (string, bool) {
 	if !strings.HasPrefix(key, "-") {
 		panic(fmt.Errorf("got unexpected key %q", key))
@@ -141,6 +230,33 @@ func appendArgsIfNoConflict(args []string, k, v string) []string {
 	return append(args, k, v)
 }
 
+// splitExtraArgs splits `vmOpts.qemu.extraArgs` into the pairs of an option and its value.
+// The value is empty for a flag such as `-s`.
+// The options that can be specified multiple times, such as `-device`, are returned as multi,
+// as they cannot be merged with appendArgsIfNoConflict.
+func splitExtraArgs(extraArgs []string) (single, multi [][2]string, err error) {
+	for i := 0; i < len(extraArgs); i++ {
+		if !strings.HasPrefix(extraArgs[i], "-") {
+			return nil, nil, fmt.Errorf("field `vmOpts.qemu.extraArgs[%d]` must be an option starting with \"-\", got %q", i, extraArgs[i])
+		}
+		idx, kv := i, [2]string{extraArgs[i], ""}
+		if i+1 < len(extraArgs) && !strings.HasPrefix(extraArgs[i+1], "-") {
+			kv[1] = extraArgs[i+1]
+			i++
+		}
+		switch kv[0] {
+		case "-name", "-pidfile", "-serial", "-parallel", "-vga", "-qmp", "-incoming":
+			// appended unconditionally by Cmdline
+			return nil, nil, fmt.Errorf("field `vmOpts.qemu.extraArgs[%d]` must not be %q, which is reserved by Lima", idx, kv[0])
+		case "-drive", "-cdrom", "-chardev", "-blockdev", "-netdev", "-device", "-object", "-global", "-fsdev", "-virtfs":
+			multi = append(multi, kv)
+		default:
+			single = append(single, kv)
+		}
+	}
+	return single, multi, nil
+}
+
 type features struct {
 	// NetdevHelp is the output of `qemu-system-x86_64 -accel help`
 	// e.g. "Accelerators supported in QEMU binary:\ntcg\nhax\nhvf\n"
@@ -150,6 +266,10 @@ type features struct {
 	// e.g. "Available netdev backend types:\nsocket\nhubport\ntap\nuser\nvde\nbridge\vhost-user\n"
 	// Not machine-readable, but checking strings.Contains() should be fine.
 	NetdevHelp []byte
+	// CPUHelp is the output of `qemu-system-x86_64 -cpu help`
+	// e.g. "Available CPUs:\nx86 486  (alias configured by machine type)\nx86 Broadwell  Intel Core Processor (Broadwell)\n"
+	// Not machine-readable, but the model names can be found with hasCPUModel().
+	CPUHelp []byte
 }
 
 func inspectFeatures(exe string) (*features, error) {
@@ -181,9 +301,35 @@ func inspectFeatures(exe string) (*features, error) {
 			f.NetdevHelp = stderr.Bytes()
 		}
 	}
+
+	stdout, stderr = bytes.Buffer{}, bytes.Buffer{}
+	cmd = exec.Command(exe, "-M", "none", "-cpu", "help")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		logrus.Warnf("failed to run %v: stdout=%q, stderr=%q", cmd.Args, stdout.String(), stderr.String())
+	} else {
+		f.CPUHelp = stdout.Bytes()
+		if len(f.CPUHelp) == 0 {
+			f.CPUHelp = stderr.Bytes()
+		}
+	}
 	return &f, nil
 }
 
+// hasCPUModel returns whether the CPU model is listed in the output of `-cpu help`.
+// The lines are formatted as "x86 Haswell-v4  Intel Core Processor ..." or "  cortex-a72".
+func hasCPUModel(cpuHelp []byte, model string) bool {
+	for _, line := range strings.Split(string(cpuHelp), "\n") {
+		for _, field := range strings.Fields(line) {
+			if field == model {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func Cmdline(cfg Config) (string, []string, error) {
 	y := cfg.LimaYAML
 	exe, args, err := getExe(y.Arch)
@@ -197,7 +343,14 @@ func Cmdline(cfg Config) (string, []string, error) {
 	}
 
 	// Architecture
-	accel := getAccel(y.Arch)
+	accel := getAccel(y.Arch, y.VMOpts.QEMU.Accel)
+	if accel != limayaml.AccelTCG && !isNativeArch(y.Arch) {
+		return "", nil, fmt.Errorf("accelerator %q cannot be used for non-native architecture %q", accel, y.Arch)
+	}
+	if !strings.Contains(string(features.AccelHelp), accel) && y.VMOpts.QEMU.Accel == "" && accel == limayaml.AccelKVM {
+		logrus.Warnf("Accelerator %q is not supported by %s, falling back to %q, which is much slower", accel, exe, limayaml.AccelTCG)
+		accel = limayaml.AccelTCG
+	}
 	if !strings.Contains(string(features.AccelHelp), accel) {
 		errStr := fmt.Sprintf("accelerator %q is not supported by %s", accel, exe)
 		if accel == "hvf" && y.Arch == limayaml.AARCH64 {
@@ -206,20 +359,33 @@ func Cmdline(cfg Config) (string, []string, error) {
 		}
 		return "", nil, errors.New(errStr)
 	}
+
+	// Extra args are added before the default values, so that they take precedence
+	extraSingleArgs, extraMultiArgs, err := splitExtraArgs(y.VMOpts.QEMU.ExtraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, kv := range extraSingleArgs {
+		args = appendArgsIfNoConflict(args, kv[0], kv[1])
+	}
+
+	cpuType := getCPUType(y.Arch, accel)
+	if s := y.CPUType[y.Arch]; s != "" {
+		cpuType = s
+		// "host" depends on the accelerator, and is not always listed
+		if model := strings.Split(cpuType, ",")[0]; model != "host" && len(features.CPUHelp) > 0 && !hasCPUModel(features.CPUHelp, model) {
+			return "", nil, fmt.Errorf("CPU model %q of `cpuType.%s` is not supported by %s, see `%s -cpu help`", model, y.Arch, exe, exe)
+		}
+	}
+	if accel == limayaml.AccelTCG && strings.Split(cpuType, ",")[0] == "host" {
+		return "", nil, fmt.Errorf("CPU type %q cannot be used with accelerator %q, set `cpuType.%s` to another CPU type such as \"max\"",
+			cpuType, accel, y.Arch)
+	}
+	args = appendArgsIfNoConflict(args, "-cpu", cpuType)
 	switch y.Arch {
 	case limayaml.X8664:
-		cpu := "Haswell-v4"
-		if isNativeArch(y.Arch) {
-			cpu = "host"
-		}
-		args = appendArgsIfNoConflict(args, "-cpu", cpu)
 		args = appendArgsIfNoConflict(args, "-machine", "q35,accel="+accel)
 	case limayaml.AARCH64:
-		cpu := "cortex-a72"
-		if isNativeArch(y.Arch) {
-			cpu = "host"
-		}
-		args = appendArgsIfNoConflict(args, "-cpu", cpu)
 		args = appendArgsIfNoConflict(args, "-machine", "virt,accel="+accel+",highmem=off")
 	}
 
@@ -265,6 +431,18 @@ func Cmdline(cfg Config) (string, []string, error) {
 	} else if !isBaseDiskCDROM {
 		args = append(args, "-drive", fmt.Sprintf("file=%s,if=virtio", baseDisk))
 	}
+	// Additional disks
+	if len(y.AdditionalDisks) > 0 {
+		disksDir, err := dirnames.LimaDisksDir()
+		if err != nil {
+			return "", nil, err
+		}
+		for i, d := range y.AdditionalDisks {
+			dataDisk := filepath.Join(disksDir, d.Name, filenames.DataDisk)
+			// The guest finds the disk as /dev/disk/by-id/virtio-lima-disk-N (see cidata.TEMPLATE.d/boot/04-additional-disks.sh)
+			args = append(args, "-drive", fmt.Sprintf("file=%s,if=virtio,format=qcow2,serial=lima-disk-%d", dataDisk, i))
+		}
+	}
 	// cloud-init
 	args = append(args, "-cdrom", filepath.Join(cfg.InstanceDir, filenames.CIDataISO))
 
@@ -345,7 +523,28 @@ func Cmdline(cfg Config) (string, []string, error) {
 	args = append(args, "-chardev", fmt.Sprintf("socket,id=%s,path=%s,server=on,wait=off,logfile=%s", serialChardev, serialSock, serialLog))
 	args = append(args, "-serial", "chardev:"+serialChardev)
 
-	// We also want to enable vsock and virtfs here, but QEMU does not support vsock and virtfs for macOS hosts
+	// We also want to enable vsock here, but QEMU does not support vsock for macOS hosts
+
+	// Mounts
+	if y.MountType == limayaml.NINEP {
+		// QEMU does not support virtfs for macOS hosts (as of QEMU 6.1)
+		for i, f := range y.Mounts {
+			location, err := localpathutil.Expand(f.Location)
+			if err != nil {
+				return "", nil, err
+			}
+			if err := os.MkdirAll(location, 0755); err != nil {
+				return "", nil, err
+			}
+			tag := fmt.Sprintf("lima-%d", i)
+			// A comma in the option value has to be escaped as ",,"
+			options := fmt.Sprintf("local,path=%s,mount_tag=%s,security_model=none,id=%s", strings.ReplaceAll(location, ",", ",,"), tag, tag)
+			if !f.Writable {
+				options += ",readonly=on"
+			}
+			args = append(args, "-virtfs", options)
+		}
+	}
 
 	// QMP
 	qmpSock := filepath.Join(cfg.InstanceDir, filenames.QMPSock)
@@ -356,6 +555,20 @@ func Cmdline(cfg Config) (string, []string, error) {
 	args = append(args, "-chardev", fmt.Sprintf("socket,id=%s,path=%s,server=on,wait=off", qmpChardev, qmpSock))
 	args = append(args, "-qmp", "chardev:"+qmpChardev)
 
+	// Restore the state saved by `limactl suspend --save`
+	vmState := filepath.Join(cfg.InstanceDir, filenames.VMState)
+	if _, err := os.Stat(vmState); err == nil {
+		args = append(args, "-incoming", "exec:cat "+shellescape.Quote(vmState))
+	}
+
+	// Extra args that can be specified multiple times, such as `-device`
+	for _, kv := range extraMultiArgs {
+		args = append(args, kv[0])
+		if kv[1] != "" {
+			args = append(args, kv[1])
+		}
+	}
+
 	// QEMU process
 	args = append(args, "-name", "lima-"+cfg.Name)
 	args = append(args, "-pidfile", filepath.Join(cfg.InstanceDir, filenames.QemuPID))
@@ -390,20 +603,61 @@ func isNativeArch(arch limayaml.Arch) bool {
 	return nativeX8664 || nativeAARCH64
 }
 
-func getAccel(arch limayaml.Arch) string {
+// getAccel returns the accelerator specified in `vmOpts.qemu.accel`, or detects the accelerator for the arch.
+// On Linux, "tcg" is returned with a warning when /dev/kvm is not accessible.
+func getAccel(arch limayaml.Arch, accel limayaml.Accel) limayaml.Accel {
+	if accel != "" {
+		return accel
+	}
 	if isNativeArch(arch) {
 		switch runtime.GOOS {
 		case "darwin":
-			return "hvf"
+			return limayaml.AccelHVF
 		case "linux":
-			return "kvm"
+			if err := checkKVM(); err != nil {
+				logrus.WithError(err).Warnf("KVM is not available, falling back to %q, which is much slower."+
+					" Set `vmOpts.qemu.accel` to %q to suppress this warning", limayaml.AccelTCG, limayaml.AccelTCG)
+				return limayaml.AccelTCG
+			}
+			return limayaml.AccelKVM
 		case "netbsd":
-			return "nvmm" // untested
+			return limayaml.AccelNVMM // untested
 		case "windows":
-			return "whpx" // untested
+			return limayaml.AccelWHPX // untested
+		}
+	}
+	return limayaml.AccelTCG
+}
+
+// checkKVM checks whether /dev/kvm can be opened for reading and writing.
+func checkKVM() error {
+	f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("%w (Hint: add the user to the \"kvm\" group)", err)
+		}
+		return err
+	}
+	return f.Close()
+}
+
+// getCPUType returns the CPU model for the arch.
+// "host" is used when the accelerator supports it, otherwise "max" is used for emulating
+// the native arch with all the features supported by TCG.
+func getCPUType(arch limayaml.Arch, accel limayaml.Accel) string {
+	if isNativeArch(arch) {
+		if accel == limayaml.AccelTCG {
+			return "max"
 		}
+		return "host"
+	}
+	switch arch {
+	case limayaml.X8664:
+		return "Haswell-v4"
+	case limayaml.AARCH64:
+		return "cortex-a72"
 	}
-	return "tcg"
+	return "max"
 }
 
 func getFirmware(qemuExe string, arch limayaml.Arch) (string, error) {
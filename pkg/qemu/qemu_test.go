@@ -1,8 +1,12 @@
 package qemu
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/lima-vm/lima/pkg/limayaml"
 	"gotest.tools/v3/assert"
 )
 
@@ -47,3 +51,139 @@ func TestArgValue(t *testing.T) {
 		assert.Equal(t, tc.expectedOK, ok)
 	}
 }
+
+func TestGetCPUType(t *testing.T) {
+	nativeArch, foreignArch, foreignCPU := limayaml.X8664, limayaml.AARCH64, "cortex-a72"
+	if runtime.GOARCH == "arm64" {
+		nativeArch, foreignArch, foreignCPU = limayaml.AARCH64, limayaml.X8664, "Haswell-v4"
+	}
+	assert.Equal(t, "host", getCPUType(nativeArch, limayaml.AccelKVM))
+	assert.Equal(t, "max", getCPUType(nativeArch, limayaml.AccelTCG))
+	assert.Equal(t, foreignCPU, getCPUType(foreignArch, limayaml.AccelTCG))
+}
+
+func TestGetAccelExplicit(t *testing.T) {
+	assert.Equal(t, limayaml.AccelTCG, getAccel(limayaml.X8664, limayaml.AccelTCG))
+	assert.Equal(t, limayaml.AccelTCG, getAccel(limayaml.AARCH64, limayaml.AccelTCG))
+}
+
+func TestSplitExtraArgs(t *testing.T) {
+	single, multi, err := splitExtraArgs([]string{
+		"-machine", "q35,accel=tcg,smm=off",
+		"-device", "virtio-balloon",
+		"-s",
+		"-device", "virtio-serial",
+		"-cpu", "qemu64,+avx2",
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, [][2]string{
+		{"-machine", "q35,accel=tcg,smm=off"},
+		{"-s", ""},
+		{"-cpu", "qemu64,+avx2"},
+	}, single)
+	assert.DeepEqual(t, [][2]string{
+		{"-device", "virtio-balloon"},
+		{"-device", "virtio-serial"},
+	}, multi)
+
+	_, _, err = splitExtraArgs([]string{"-machine", "a", "b"})
+	assert.ErrorContains(t, err, "extraArgs[2]")
+
+	_, _, err = splitExtraArgs([]string{"-s", "-serial", "stdio"})
+	assert.ErrorContains(t, err, "field `vmOpts.qemu.extraArgs[1]` must not be \"-serial\"")
+}
+
+// fakeQEMU sets up a fake qemu-system-x86_64 that prints the output of `-accel help` and `-cpu help`.
+func fakeQEMU(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "qemu-system-x86_64")
+	script := `#!/bin/sh
+case "$*" in
+*"-accel help"*) printf 'Accelerators supported in QEMU binary:\ntcg\nkvm\n' ;;
+*"-cpu help"*) printf 'Available CPUs:\nx86 Haswell-v4  Intel Core Processor (Haswell, no TSX, IBRS)\nx86 max  Enables all features supported by the accelerator in the current host\nx86 qemu64  QEMU Virtual CPU version 2.5+\n' ;;
+esac
+`
+	assert.NilError(t, os.WriteFile(exe, []byte(script), 0755))
+	t.Setenv("QEMU_SYSTEM_X86_64", exe)
+}
+
+func testConfig(t *testing.T) Config {
+	instDir := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(instDir, "basedisk"), nil, 0644))
+	return Config{
+		Name:        "test",
+		InstanceDir: instDir,
+		LimaYAML: &limayaml.LimaYAML{
+			Arch:     limayaml.X8664,
+			CPUs:     4,
+			Memory:   "4GiB",
+			Disk:     "100GiB",
+			Firmware: limayaml.Firmware{LegacyBIOS: true},
+			VMOpts:   limayaml.VMOpts{QEMU: limayaml.QEMUOpts{Accel: limayaml.AccelTCG}},
+		},
+		SSHLocalPort: 60022,
+	}
+}
+
+func TestCmdlineExtraArgs(t *testing.T) {
+	fakeQEMU(t)
+	cfg := testConfig(t)
+	_, args, err := Cmdline(cfg)
+	assert.NilError(t, err)
+	v, _ := argValue(args, "-machine")
+	assert.Equal(t, "q35,accel=tcg", v)
+	v, _ = argValue(args, "-m")
+	assert.Equal(t, "4096", v)
+
+	// the options that can be specified only once take precedence over the default values
+	cfg.LimaYAML.VMOpts.QEMU.ExtraArgs = []string{"-machine", "q35,accel=tcg,smm=off", "-m", "2048", "-device", "virtio-balloon"}
+	_, args, err = Cmdline(cfg)
+	assert.NilError(t, err)
+	v, _ = argValue(args, "-machine")
+	assert.Equal(t, "q35,accel=tcg,smm=off", v)
+	v, _ = argValue(args, "-m")
+	assert.Equal(t, "2048", v)
+	var count int
+	for _, arg := range args {
+		if arg == "-machine" || arg == "-m" {
+			count++
+		}
+	}
+	assert.Equal(t, 2, count)
+	// the options that can be specified multiple times are appended before `-name` and `-pidfile`
+	assert.DeepEqual(t, []string{"-device", "virtio-balloon", "-name", "lima-test"}, args[len(args)-6:len(args)-2])
+
+	// the options appended unconditionally cannot be overridden
+	for _, k := range []string{"-name", "-pidfile", "-serial", "-parallel", "-vga", "-qmp", "-incoming"} {
+		cfg.LimaYAML.VMOpts.QEMU.ExtraArgs = []string{k, "foo"}
+		_, _, err = Cmdline(cfg)
+		assert.ErrorContains(t, err, "reserved by Lima", k)
+	}
+}
+
+func TestCmdlineCPUType(t *testing.T) {
+	fakeQEMU(t)
+	cfg := testConfig(t)
+
+	cfg.LimaYAML.CPUType = map[limayaml.Arch]string{limayaml.X8664: "Haswell-v4,+avx2"}
+	_, args, err := Cmdline(cfg)
+	assert.NilError(t, err)
+	v, _ := argValue(args, "-cpu")
+	assert.Equal(t, "Haswell-v4,+avx2", v)
+
+	cfg.LimaYAML.CPUType = map[limayaml.Arch]string{limayaml.X8664: "Haswel"}
+	_, _, err = Cmdline(cfg)
+	assert.ErrorContains(t, err, "CPU model \"Haswel\" of `cpuType.x86_64` is not supported")
+
+	cfg.LimaYAML.CPUType = map[limayaml.Arch]string{limayaml.X8664: "host"}
+	_, _, err = Cmdline(cfg)
+	assert.ErrorContains(t, err, "cannot be used with accelerator \"tcg\"")
+}
+
+func TestHasCPUModel(t *testing.T) {
+	cpuHelp := []byte("Available CPUs:\nx86 Haswell-v4  Intel Core Processor (Haswell, no TSX, IBRS)\n  cortex-a72\n")
+	assert.Assert(t, hasCPUModel(cpuHelp, "Haswell-v4"))
+	assert.Assert(t, hasCPUModel(cpuHelp, "cortex-a72"))
+	assert.Assert(t, !hasCPUModel(cpuHelp, "Haswell"))
+	assert.Assert(t, !hasCPUModel(cpuHelp, "cortex"))
+}
@@ -0,0 +1,102 @@
+package qemu
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/digitalocean/go-qemu/qmp/raw"
+	"github.com/lima-vm/lima/pkg/qemu/imgutil"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+)
+
+// SaveSnapshot creates the snapshot.
+// When run is true, the snapshot is created with `savevm` via QMP, and contains the VM state as well as the disk.
+// Otherwise the snapshot is created with `qemu-img snapshot -c` and only contains the disk.
+func SaveSnapshot(cfg Config, tag string, run bool) error {
+	if run {
+		return humanMonitorCommand(cfg, "savevm "+tag)
+	}
+	return imgSnapshot(cfg, "-c", tag)
+}
+
+// LoadSnapshot applies the snapshot.
+// A disk-only snapshot (created while the instance was stopped) cannot be applied to a running instance.
+func LoadSnapshot(cfg Config, tag string, run bool) error {
+	if run {
+		return humanMonitorCommand(cfg, "loadvm "+tag)
+	}
+	return imgSnapshot(cfg, "-a", tag)
+}
+
+// DeleteSnapshot deletes the snapshot.
+func DeleteSnapshot(cfg Config, tag string, run bool) error {
+	if run {
+		return humanMonitorCommand(cfg, "delvm "+tag)
+	}
+	return imgSnapshot(cfg, "-d", tag)
+}
+
+// ListSnapshots lists the snapshots of the diffdisk.
+func ListSnapshots(cfg Config) ([]imgutil.Snapshot, error) {
+	diffDisk, err := ensureDiffDisk(cfg)
+	if err != nil {
+		return nil, err
+	}
+	imgInfo, err := imgutil.GetInfoShared(diffDisk)
+	if err != nil {
+		return nil, err
+	}
+	return imgInfo.Snapshots, nil
+}
+
+func ensureDiffDisk(cfg Config) (string, error) {
+	diffDisk := filepath.Join(cfg.InstanceDir, filenames.DiffDisk)
+	if _, err := os.Stat(diffDisk); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("instance %q does not have %q, snapshots are not supported", cfg.Name, filenames.DiffDisk)
+		}
+		return "", err
+	}
+	return diffDisk, nil
+}
+
+func imgSnapshot(cfg Config, flag, tag string) error {
+	diffDisk, err := ensureDiffDisk(cfg)
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("qemu-img", "snapshot", flag, tag, diffDisk)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logrus.Debugf("executing %v", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %v: stdout=%q, stderr=%q: %w",
+			cmd.Args, stdout.String(), stderr.String(), err)
+	}
+	return nil
+}
+
+func humanMonitorCommand(cfg Config, commandLine string) error {
+	if _, err := ensureDiffDisk(cfg); err != nil {
+		return err
+	}
+	return withQMP(cfg, func(rawClient *raw.Monitor) error {
+		logrus.Debugf("sending %q via the QMP socket", commandLine)
+		out, err := rawClient.HumanMonitorCommand(commandLine, nil)
+		if err != nil {
+			return fmt.Errorf("failed to send %q via the QMP socket: %w", commandLine, err)
+		}
+		// HMP commands such as savevm report errors as the output, not as QMP errors
+		if out = strings.TrimSpace(out); out != "" {
+			return fmt.Errorf("failed to run %q: %s", commandLine, out)
+		}
+		return nil
+	})
+}
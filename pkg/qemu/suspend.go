@@ -0,0 +1,153 @@
+package qemu
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"github.com/digitalocean/go-qemu/qmp"
+	"github.com/digitalocean/go-qemu/qmp/raw"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+)
+
+// Suspend pauses the vCPUs with the QMP `stop` command.
+// The instance is marked as suspended with filenames.SuspendedMarker, until Resume is called.
+// The marker is created before pausing the vCPUs, so that the hostagent does not run the liveness probes against the paused instance.
+func Suspend(cfg Config) error {
+	marker := filepath.Join(cfg.InstanceDir, filenames.SuspendedMarker)
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		return err
+	}
+	if err := withQMP(cfg, func(rawClient *raw.Monitor) error {
+		return rawClient.Stop()
+	}); err != nil {
+		_ = os.RemoveAll(marker)
+		return err
+	}
+	return nil
+}
+
+// Resume resumes the vCPUs paused by Suspend, with the QMP `cont` command.
+func Resume(cfg Config) error {
+	if err := withQMP(cfg, func(rawClient *raw.Monitor) error {
+		return rawClient.Cont()
+	}); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(cfg.InstanceDir, filenames.SuspendedMarker))
+}
+
+// SaveState saves the state of the suspended VM to filenames.VMState with the QMP `migrate` command.
+// The VM can be restored from the file by starting QEMU with Cmdline, which adds `-incoming` when the file exists.
+//
+// The QEMU process has to be killed without shutting down the guest after SaveState,
+// as the disk must not be modified until the state is restored.
+func SaveState(cfg Config, timeout time.Duration) error {
+	vmState := filepath.Join(cfg.InstanceDir, filenames.VMState)
+	vmStateTmp := vmState + ".tmp"
+	if err := os.RemoveAll(vmStateTmp); err != nil {
+		return err
+	}
+	uri := "exec:cat >" + shellescape.Quote(vmStateTmp)
+	if err := withQMP(cfg, func(rawClient *raw.Monitor) error {
+		logrus.Debugf("sending QMP migrate command (uri=%q)", uri)
+		if err := rawClient.Migrate(uri, nil, nil, nil); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(timeout)
+		for {
+			info, err := rawClient.QueryMigrate()
+			if err != nil {
+				return err
+			}
+			if info.Status != nil {
+				switch *info.Status {
+				case raw.MigrationStatusCompleted:
+					return nil
+				case raw.MigrationStatusFailed, raw.MigrationStatusCancelled:
+					if info.ErrorDesc != nil {
+						return fmt.Errorf("migration %s: %s", *info.Status, *info.ErrorDesc)
+					}
+					return fmt.Errorf("migration %s", *info.Status)
+				}
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("migration did not complete in %v", timeout)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}); err != nil {
+		_ = os.RemoveAll(vmStateTmp)
+		return fmt.Errorf("failed to save the VM state to %q: %w", vmState, err)
+	}
+	return os.Rename(vmStateTmp, vmState)
+}
+
+// ResumeRestoredState waits for QEMU to load the state saved by SaveState, and resumes the vCPUs.
+// The state file is removed on success, so that the next boot starts from scratch.
+func ResumeRestoredState(cfg Config, timeout time.Duration) error {
+	vmState := filepath.Join(cfg.InstanceDir, filenames.VMState)
+	deadline := time.Now().Add(timeout)
+	if err := waitQMP(cfg, deadline); err != nil {
+		return fmt.Errorf("failed to restore the VM state from %q: %w", vmState, err)
+	}
+	if err := withQMP(cfg, func(rawClient *raw.Monitor) error {
+		for {
+			st, err := rawClient.QueryStatus()
+			if err != nil {
+				return err
+			}
+			switch st.Status {
+			case raw.RunStateInmigrate:
+				// NOP
+			case raw.RunStateRunning:
+				return nil
+			default:
+				// The state was saved while the vCPUs were paused
+				return rawClient.Cont()
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("the VM state was not loaded in %v", timeout)
+			}
+			time.Sleep(500 * time.Millisecond)
+		}
+	}); err != nil {
+		return fmt.Errorf("failed to restore the VM state from %q: %w", vmState, err)
+	}
+	if err := os.RemoveAll(filepath.Join(cfg.InstanceDir, filenames.SuspendedMarker)); err != nil {
+		return err
+	}
+	return os.RemoveAll(vmState)
+}
+
+// waitQMP waits for QEMU to create the QMP socket, which is missing just after starting QEMU.
+func waitQMP(cfg Config, deadline time.Time) error {
+	qmpSockPath := filepath.Join(cfg.InstanceDir, filenames.QMPSock)
+	for {
+		conn, err := net.Dial("unix", qmpSockPath)
+		if err == nil {
+			return conn.Close()
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("failed to connect to the QMP socket %q: %w", qmpSockPath, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func withQMP(cfg Config, f func(rawClient *raw.Monitor) error) error {
+	qmpSockPath := filepath.Join(cfg.InstanceDir, filenames.QMPSock)
+	qmpClient, err := qmp.NewSocketMonitor("unix", qmpSockPath, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to open the QMP socket %q: %w", qmpSockPath, err)
+	}
+	if err := qmpClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to the QMP socket %q: %w", qmpSockPath, err)
+	}
+	defer func() { _ = qmpClient.Disconnect() }()
+	return f(raw.NewMonitor(qmpClient))
+}
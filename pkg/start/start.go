@@ -9,10 +9,12 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/lima-vm/lima/pkg/downloader"
 	hostagentevents "github.com/lima-vm/lima/pkg/hostagent/events"
 	"github.com/lima-vm/lima/pkg/limayaml"
 	"github.com/lima-vm/lima/pkg/qemu"
+	"github.com/lima-vm/lima/pkg/qemu/imgutil"
 	"github.com/lima-vm/lima/pkg/store"
 	"github.com/lima-vm/lima/pkg/store/filenames"
 	"github.com/sirupsen/logrus"
@@ -28,6 +30,38 @@ func ensureDisk(ctx context.Context, instName, instDir string, y *limayaml.LimaY
 		return err
 	}
 
+	// EnsureDisk does nothing when the diffdisk already exists, so `disk` might have been changed since then
+	diskSize, _ := units.RAMInBytes(y.Disk)
+	diffDisk := filepath.Join(instDir, filenames.DiffDisk)
+	if _, err := os.Stat(diffDisk); err == nil && diskSize > 0 {
+		diffDiskInfo, err := imgutil.GetInfo(diffDisk)
+		if err != nil {
+			return err
+		}
+		switch {
+		case diskSize > diffDiskInfo.VSize:
+			if err := qemu.ResizeDisk(qCfg, diskSize); err != nil {
+				return fmt.Errorf("failed to resize the disk to %q: %w", y.Disk, err)
+			}
+		case diskSize < diffDiskInfo.VSize:
+			logrus.Warnf("Shrinking the disk from %s to %q is not supported, ignoring `disk`",
+				units.BytesSize(float64(diffDiskInfo.VSize)), y.Disk)
+		}
+	}
+
+	for _, d := range y.AdditionalDisks {
+		if _, err := store.InspectDisk(d.Name); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("additional disk %q does not exist, run `limactl disk create %s --size SIZE` to create it", d.Name, d.Name)
+			}
+			return err
+		}
+	}
+	// The disks are unlocked by the hostagent after QEMU exits
+	if err := store.LockDisks(instDir, y); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -74,7 +108,70 @@ func ensureNerdctlArchiveCache(y *limayaml.LimaYAML) (string, error) {
 		len(y.Containerd.Archives), errs)
 }
 
-func Start(ctx context.Context, inst *store.Instance) error {
+// ensureProvisionFilesCache prefetches the remote files of `provision` into the cache before launching the hostagent process,
+// so that we can show the progress in tty. The hostagent reads the files from the cache when it generates cidata.iso.
+// The files without the digest are not cached, as the hostagent downloads them every time.
+func ensureProvisionFilesCache(y *limayaml.LimaYAML) error {
+	for _, p := range y.Provision {
+		if p.File == nil || p.File.Digest == "" || downloader.IsLocal(p.File.Location) {
+			continue
+		}
+		logrus.WithField("digest", p.File.Digest).Infof("Attempting to download the file of provision %q from %q", p.Name, p.File.Location)
+		res, err := downloader.Download("", p.File.Location, downloader.WithCache(), downloader.WithExpectedDigest(p.File.Digest))
+		if err != nil {
+			return fmt.Errorf("failed to download %q: %w", p.File.Location, err)
+		}
+		switch res.Status {
+		case downloader.StatusDownloaded:
+			logrus.Infof("Downloaded the file of provision %q from %q", p.Name, p.File.Location)
+		case downloader.StatusUsedCache:
+			logrus.Infof("Using cache %q", res.CachePath)
+		default:
+			logrus.Warnf("Unexpected result from downloader.Download(): %+v", res)
+		}
+	}
+	return nil
+}
+
+type options struct {
+	waitPhase hostagentevents.Phase // default: hostagentevents.PhaseReady
+	timeout   time.Duration         // default: bootTimeout + 5 minutes
+}
+
+type Opt func(*options) error
+
+// WithWaitPhase makes Start return when the hostagent reaches the phase, rather than waiting for the "ready" phase.
+func WithWaitPhase(phase hostagentevents.Phase) Opt {
+	return func(o *options) error {
+		if hostagentevents.PhaseIndex(phase) < 0 {
+			return fmt.Errorf("unknown phase %q, must be one of %v", phase, hostagentevents.Phases)
+		}
+		o.waitPhase = phase
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout for waiting for the phase.
+func WithTimeout(d time.Duration) Opt {
+	return func(o *options) error {
+		if d <= 0 {
+			return fmt.Errorf("timeout must be positive, got %v", d)
+		}
+		o.timeout = d
+		return nil
+	}
+}
+
+func Start(ctx context.Context, inst *store.Instance, opts ...Opt) error {
+	o := options{
+		waitPhase: hostagentevents.PhaseReady,
+	}
+	for _, f := range opts {
+		if err := f(&o); err != nil {
+			return err
+		}
+	}
+
 	haPIDPath := filepath.Join(inst.Dir, filenames.HostAgentPID)
 	if _, err := os.Stat(haPIDPath); !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("instance %q seems running (hint: remove %q if the instance is not actually running)", inst.Name, haPIDPath)
@@ -94,6 +191,9 @@ func Start(ctx context.Context, inst *store.Instance) error {
 	if err != nil {
 		return err
 	}
+	if err := ensureProvisionFilesCache(y); err != nil {
+		return err
+	}
 
 	self, err := os.Executable()
 	if err != nil {
@@ -145,9 +245,18 @@ func Start(ctx context.Context, inst *store.Instance) error {
 		return err
 	}
 
+	watchTimeout := o.timeout
+	if watchTimeout == 0 {
+		bootTimeout, err := time.ParseDuration(y.BootTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to parse bootTimeout %q: %w", y.BootTimeout, err)
+		}
+		// The hostagent gives up the requirements after bootTimeout; the grace period covers the mounts and the ansible playbooks.
+		watchTimeout = bootTimeout + 5*time.Minute
+	}
 	watchErrCh := make(chan error)
 	go func() {
-		watchErrCh <- watchHostAgentEvents(ctx, inst.Name, haStdoutPath, haStderrPath, begin)
+		watchErrCh <- watchHostAgentEvents(ctx, inst.Name, haStdoutPath, haStderrPath, begin, o.waitPhase, watchTimeout)
 		close(watchErrCh)
 	}()
 	waitErrCh := make(chan error)
@@ -181,20 +290,25 @@ func waitHostAgentStart(ctx context.Context, haPIDPath, haStderrPath string) err
 	}
 }
 
-func watchHostAgentEvents(ctx context.Context, instName, haStdoutPath, haStderrPath string, begin time.Time) error {
-	ctx2, cancel := context.WithTimeout(ctx, 10*time.Minute)
+func watchHostAgentEvents(ctx context.Context, instName, haStdoutPath, haStderrPath string, begin time.Time, waitPhase hostagentevents.Phase, timeout time.Duration) error {
+	ctx2, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var (
-		printedSSHLocalPort  bool
-		receivedRunningEvent bool
-		err                  error
+		printedSSHLocalPort bool
+		printedPhase        hostagentevents.Phase
+		reachedWaitPhase    bool
+		err                 error
 	)
 	onEvent := func(ev hostagentevents.Event) bool {
 		if !printedSSHLocalPort && ev.Status.SSHLocalPort != 0 {
 			logrus.Infof("SSH Local Port: %d", ev.Status.SSHLocalPort)
 			printedSSHLocalPort = true
 		}
+		if ev.Status.Phase != "" && ev.Status.Phase != printedPhase {
+			logrus.Infof("Phase: %s", ev.Status.Phase)
+			printedPhase = ev.Status.Phase
+		}
 
 		if len(ev.Status.Errors) > 0 {
 			logrus.Errorf("%+v", ev.Status.Errors)
@@ -203,7 +317,7 @@ func watchHostAgentEvents(ctx context.Context, instName, haStdoutPath, haStderrP
 			err = fmt.Errorf("exiting, status=%+v (hint: see %q)", ev.Status, haStderrPath)
 			return true
 		} else if ev.Status.Running {
-			receivedRunningEvent = true
+			reachedWaitPhase = true
 			if ev.Status.Degraded {
 				logrus.Warnf("DEGRADED. The VM seems running, but file sharing and port forwarding may not work. (hint: see %q)", haStderrPath)
 				err = fmt.Errorf("degraded, status=%+v", ev.Status)
@@ -213,6 +327,11 @@ func watchHostAgentEvents(ctx context.Context, instName, haStdoutPath, haStderrP
 			logrus.Infof("READY. Run `%s` to open the shell.", LimactlShellCmd(instName))
 			err = nil
 			return true
+		} else if waitPhase != hostagentevents.PhaseReady && hostagentevents.PhaseIndex(ev.Status.Phase) >= hostagentevents.PhaseIndex(waitPhase) {
+			reachedWaitPhase = true
+			logrus.Infof("Reached the %q phase. The remaining phases continue in the background. Run `limactl list` to check the status.", ev.Status.Phase)
+			err = nil
+			return true
 		}
 		return false
 	}
@@ -225,8 +344,11 @@ func watchHostAgentEvents(ctx context.Context, instName, haStdoutPath, haStderrP
 		return err
 	}
 
-	if !receivedRunningEvent {
-		return errors.New("did not receive an event with the \"running\" status")
+	if !reachedWaitPhase {
+		if waitPhase == hostagentevents.PhaseReady {
+			return fmt.Errorf("did not receive an event with the \"running\" status in %v", timeout)
+		}
+		return fmt.Errorf("did not reach the %q phase in %v", waitPhase, timeout)
 	}
 
 	return nil
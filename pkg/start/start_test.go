@@ -0,0 +1,94 @@
+package start
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hostagentevents "github.com/lima-vm/lima/pkg/hostagent/events"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"gotest.tools/v3/assert"
+)
+
+func TestEnsureProvisionFilesCacheWithoutDigest(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	t.Setenv("HOME", cacheHome)
+	var count int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+	}))
+	defer ts.Close()
+
+	y := &limayaml.LimaYAML{
+		Provision: []limayaml.Provision{
+			{Mode: limayaml.ProvisionModeSystem, File: &limayaml.File{Location: ts.URL + "/provision.sh"}},
+		},
+	}
+	assert.NilError(t, ensureProvisionFilesCache(y))
+	// the file is downloaded by the hostagent, not prefetched into the cache
+	assert.Equal(t, 0, count)
+	entries, err := os.ReadDir(cacheHome)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
+
+func TestWatchHostAgentEvents(t *testing.T) {
+	type testCase struct {
+		name      string
+		waitPhase hostagentevents.Phase
+		statuses  []hostagentevents.Status
+		err       string
+	}
+	booting := hostagentevents.Status{Phase: hostagentevents.PhaseBooting, SSHLocalPort: 60022}
+	sshReady := hostagentevents.Status{Phase: hostagentevents.PhaseSSHReady, SSHLocalPort: 60022}
+	provisioned := hostagentevents.Status{Phase: hostagentevents.PhaseProvisioned, SSHLocalPort: 60022}
+	ready := hostagentevents.Status{Phase: hostagentevents.PhaseReady, Running: true, SSHLocalPort: 60022}
+	degraded := hostagentevents.Status{Phase: hostagentevents.PhaseReady, Running: true, Degraded: true, SSHLocalPort: 60022}
+	exiting := hostagentevents.Status{Exiting: true, SSHLocalPort: 60022}
+	testCases := []testCase{
+		{name: "ready", waitPhase: hostagentevents.PhaseReady, statuses: []hostagentevents.Status{booting, sshReady, provisioned, ready}},
+		{name: "ready, degraded", waitPhase: hostagentevents.PhaseReady, statuses: []hostagentevents.Status{booting, degraded}, err: "degraded"},
+		{name: "ready, timeout", waitPhase: hostagentevents.PhaseReady, statuses: []hostagentevents.Status{booting, provisioned}, err: "did not receive an event with the \"running\" status"},
+		{name: "ssh-ready", waitPhase: hostagentevents.PhaseSSHReady, statuses: []hostagentevents.Status{booting, sshReady}},
+		// a later phase satisfies the requested phase
+		{name: "mounts-ready, reached provisioned", waitPhase: hostagentevents.PhaseMountsReady, statuses: []hostagentevents.Status{booting, provisioned}},
+		{name: "provisioned, degraded", waitPhase: hostagentevents.PhaseProvisioned, statuses: []hostagentevents.Status{booting, sshReady, degraded}, err: "degraded"},
+		{name: "provisioned, timeout", waitPhase: hostagentevents.PhaseProvisioned, statuses: []hostagentevents.Status{booting, sshReady}, err: "did not reach the \"provisioned\" phase"},
+		{name: "booting, exiting", waitPhase: hostagentevents.PhaseSSHReady, statuses: []hostagentevents.Status{booting, exiting}, err: "exiting"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			haStdoutPath := filepath.Join(dir, "ha.stdout.log")
+			haStderrPath := filepath.Join(dir, "ha.stderr.log")
+			f, err := os.Create(haStdoutPath)
+			assert.NilError(t, err)
+			enc := json.NewEncoder(f)
+			for _, st := range tc.statuses {
+				assert.NilError(t, enc.Encode(hostagentevents.Event{Time: time.Now(), Status: st}))
+			}
+			assert.NilError(t, f.Close())
+			assert.NilError(t, os.WriteFile(haStderrPath, nil, 0644))
+
+			err = watchHostAgentEvents(context.Background(), "test", haStdoutPath, haStderrPath, time.Now(), tc.waitPhase, time.Second)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestWithWaitPhase(t *testing.T) {
+	var o options
+	assert.NilError(t, WithWaitPhase(hostagentevents.PhaseSSHReady)(&o))
+	assert.Equal(t, hostagentevents.PhaseSSHReady, o.waitPhase)
+	assert.ErrorContains(t, WithWaitPhase("unknown")(&o), "unknown phase \"unknown\"")
+}
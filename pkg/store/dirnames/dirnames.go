@@ -45,6 +45,15 @@ func LimaConfigDir() (string, error) {
 	return filepath.Join(limaDir, filenames.ConfigDir), nil
 }
 
+// LimaDisksDir returns the path of the disks directory, $LIMA_HOME/_disks.
+func LimaDisksDir() (string, error) {
+	limaDir, err := LimaDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(limaDir, filenames.DisksDir), nil
+}
+
 // LimaNetworksDir returns the path of the networks log directory, $LIMA_HOME/_networks.
 func LimaNetworksDir() (string, error) {
 	limaDir, err := LimaDir()
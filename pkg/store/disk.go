@@ -0,0 +1,228 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/identifiers"
+	"github.com/hashicorp/go-multierror"
+	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/lockutil"
+	"github.com/lima-vm/lima/pkg/qemu/imgutil"
+	"github.com/lima-vm/lima/pkg/store/dirnames"
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
+)
+
+type Disk struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"` // bytes
+	Dir         string `json:"dir"`
+	Instance    string `json:"instance,omitempty"` // the instance that holds the disk
+	InstanceDir string `json:"instanceDir,omitempty"`
+}
+
+// Disks returns the names of the additional disks under LimaDisksDir.
+func Disks() ([]string, error) {
+	disksDir, err := dirnames.LimaDisksDir()
+	if err != nil {
+		return nil, err
+	}
+	disksDirList, err := os.ReadDir(disksDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, f := range disksDirList {
+		if strings.HasPrefix(f.Name(), ".") || strings.HasPrefix(f.Name(), "_") {
+			continue
+		}
+		names = append(names, f.Name())
+	}
+	return names, nil
+}
+
+// DiskDir returns the disk dir.
+// DiskDir does not check whether the disk exists
+func DiskDir(name string) (string, error) {
+	if err := identifiers.Validate(name); err != nil {
+		return "", err
+	}
+	disksDir, err := dirnames.LimaDisksDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(disksDir, name), nil
+}
+
+// InspectDisk returns err only when the disk does not exist (os.ErrNotExist), or cannot be inspected.
+func InspectDisk(name string) (*Disk, error) {
+	disk, err := lookupDisk(name)
+	if err != nil {
+		return nil, err
+	}
+	// The disk may be in use by a running instance
+	info, err := imgutil.GetInfoShared(filepath.Join(disk.Dir, filenames.DataDisk))
+	if err != nil {
+		return nil, err
+	}
+	disk.Size = info.VSize
+	holder, err := diskHolder(disk.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if holder != "" {
+		disk.Instance, disk.InstanceDir = filepath.Base(holder), holder
+	}
+	return disk, nil
+}
+
+// lookupDisk is similar to InspectDisk, but does not fill Size, Instance, and InstanceDir.
+// lookupDisk does not need qemu-img.
+func lookupDisk(name string) (*Disk, error) {
+	diskDir, err := DiskDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(diskDir, filenames.DataDisk)); err != nil {
+		return nil, err
+	}
+	return &Disk{
+		Name: name,
+		Dir:  diskDir,
+	}, nil
+}
+
+// diskHolder returns the instance dir that holds the disk, or "" when no active instance holds the disk.
+// The lock of an inactive instance is stale, e.g., after a crash of the hostagent.
+// A lock is never stale while the process that took the lock is running, as `limactl start`
+// takes the lock before launching the hostagent.
+func diskHolder(diskDir string) (string, error) {
+	instDir, err := os.Readlink(filepath.Join(diskDir, filenames.InUseBy))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	if pid, err := ReadPIDFile(filepath.Join(diskDir, filenames.InUseByPID)); err == nil && pid > 0 {
+		return instDir, nil
+	}
+	if !instanceActive(instDir) {
+		return "", nil
+	}
+	return instDir, nil
+}
+
+// instanceActive returns whether the instance may be using its disks:
+// the hostagent or QEMU is running, or the state is saved by `limactl suspend --save`.
+func instanceActive(instDir string) bool {
+	for _, f := range []string{filenames.HostAgentPID, filenames.QemuPID} {
+		if pid, err := ReadPIDFile(filepath.Join(instDir, f)); err == nil && pid > 0 {
+			return true
+		}
+	}
+	_, err := os.Stat(filepath.Join(instDir, filenames.VMState))
+	return err == nil
+}
+
+// Lock records that the instance holds the disk.
+// Lock fails when the disk is held by another active instance.
+func (d *Disk) Lock(instDir string) error {
+	return lockutil.WithDirLock(d.Dir, func() error {
+		holder, err := diskHolder(d.Dir)
+		if err != nil {
+			return err
+		}
+		if holder != "" && holder != instDir {
+			return fmt.Errorf("disk %q is in use by instance %q", d.Name, filepath.Base(holder))
+		}
+		inUseBy := filepath.Join(d.Dir, filenames.InUseBy)
+		if err := os.RemoveAll(inUseBy); err != nil {
+			return err
+		}
+		if err := os.Symlink(instDir, inUseBy); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(d.Dir, filenames.InUseByPID), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			return err
+		}
+		d.Instance, d.InstanceDir = filepath.Base(instDir), instDir
+		return nil
+	})
+}
+
+// Unlock releases the disk held by the instance.
+// Unlock does nothing when the disk is held by another instance.
+func (d *Disk) Unlock(instDir string) error {
+	return lockutil.WithDirLock(d.Dir, func() error {
+		inUseBy := filepath.Join(d.Dir, filenames.InUseBy)
+		holder, err := os.Readlink(inUseBy)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if holder != instDir {
+			return nil
+		}
+		d.Instance, d.InstanceDir = "", ""
+		if err := os.RemoveAll(filepath.Join(d.Dir, filenames.InUseByPID)); err != nil {
+			return err
+		}
+		return os.Remove(inUseBy)
+	})
+}
+
+// LockDisks locks the additional disks of the instance.
+// When one of the disks cannot be locked, the disks locked so far are unlocked.
+func LockDisks(instDir string, y *limayaml.LimaYAML) error {
+	var locked []*Disk
+	for _, d := range y.AdditionalDisks {
+		disk, err := lookupDisk(d.Name)
+		if err == nil {
+			err = disk.Lock(instDir)
+		}
+		if err != nil {
+			for _, disk := range locked {
+				if unlockErr := disk.Unlock(instDir); unlockErr != nil {
+					logrus.WithError(unlockErr).Warnf("failed to unlock disk %q", disk.Name)
+				}
+			}
+			return err
+		}
+		locked = append(locked, disk)
+	}
+	return nil
+}
+
+// UnlockDisks unlocks the additional disks of the instance,
+// unless the state of the instance is saved by `limactl suspend --save`.
+func UnlockDisks(instDir string, y *limayaml.LimaYAML) error {
+	if _, err := os.Stat(filepath.Join(instDir, filenames.VMState)); err == nil {
+		return nil
+	}
+	var mErr error
+	for _, d := range y.AdditionalDisks {
+		disk, err := lookupDisk(d.Name)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			mErr = multierror.Append(mErr, err)
+			continue
+		}
+		if err := disk.Unlock(instDir); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+	}
+	return mErr
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lima-vm/lima/pkg/store/filenames"
+	"gotest.tools/v3/assert"
+)
+
+func TestDiskLock(t *testing.T) {
+	limaHome := t.TempDir()
+	t.Setenv("LIMA_HOME", limaHome)
+
+	diskDir := filepath.Join(limaHome, filenames.DisksDir, "data")
+	assert.NilError(t, os.MkdirAll(diskDir, 0700))
+	assert.NilError(t, os.WriteFile(filepath.Join(diskDir, filenames.DataDisk), nil, 0644))
+	instA := filepath.Join(limaHome, "a")
+	instB := filepath.Join(limaHome, "b")
+	assert.NilError(t, os.MkdirAll(instA, 0700))
+	assert.NilError(t, os.MkdirAll(instB, 0700))
+
+	disk, err := lookupDisk("data")
+	assert.NilError(t, err)
+	assert.NilError(t, disk.Lock(instA))
+	assert.Equal(t, disk.Instance, "a")
+
+	// The fresh lock is held while the process that took the lock is running,
+	// even before the hostagent of "a" starts
+	assert.ErrorContains(t, disk.Lock(instB), `disk "data" is in use by instance "a"`)
+	assert.NilError(t, os.Remove(filepath.Join(diskDir, filenames.InUseByPID)))
+	holder, err := diskHolder(diskDir)
+	assert.NilError(t, err)
+	assert.Equal(t, holder, "")
+
+	// "a" is active while its hostagent is running
+	assert.NilError(t, os.WriteFile(filepath.Join(instA, filenames.HostAgentPID), []byte(strconv.Itoa(os.Getpid())), 0644))
+	holder, err = diskHolder(diskDir)
+	assert.NilError(t, err)
+	assert.Equal(t, holder, instA)
+	assert.ErrorContains(t, disk.Lock(instB), `disk "data" is in use by instance "a"`)
+
+	// The lock of an inactive instance is stale
+	assert.NilError(t, os.Remove(filepath.Join(instA, filenames.HostAgentPID)))
+	assert.NilError(t, disk.Lock(instB))
+	_, err = os.Stat(filepath.Join(diskDir, filenames.InUseByPID))
+	assert.NilError(t, err)
+
+	// Unlock does not release the lock held by another instance
+	assert.NilError(t, disk.Unlock(instA))
+	holder, err = os.Readlink(filepath.Join(diskDir, filenames.InUseBy))
+	assert.NilError(t, err)
+	assert.Equal(t, holder, instB)
+	assert.NilError(t, disk.Unlock(instB))
+	_, err = os.Lstat(filepath.Join(diskDir, filenames.InUseBy))
+	assert.Assert(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(diskDir, filenames.InUseByPID))
+	assert.Assert(t, os.IsNotExist(err))
+}
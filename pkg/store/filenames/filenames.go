@@ -10,6 +10,7 @@ const (
 	ConfigDir   = "_config"
 	CacheDir    = "_cache"    // not yet implemented
 	NetworksDir = "_networks" // network log files are stored here
+	DisksDir    = "_disks"    // additional disks are stored here
 )
 
 // Filenames used inside the ConfigDir
@@ -20,23 +21,34 @@ const (
 	NetworksConfig = "networks.yaml"
 )
 
+// Filenames used under a disk directory
+
+const (
+	DataDisk   = "datadisk"
+	InUseBy    = "in_use_by"     // symlink to the instance dir that holds the disk
+	InUseByPID = "in_use_by.pid" // PID of the process that locked the disk, e.g., `limactl start`
+)
+
 // Filenames that may appear under an instance directory
 
 const (
-	LimaYAML           = "lima.yaml"
-	CIDataISO          = "cidata.iso"
-	BaseDisk           = "basedisk"
-	DiffDisk           = "diffdisk"
-	QemuPID            = "qemu.pid"
-	QMPSock            = "qmp.sock"
-	SerialLog          = "serial.log"
-	SerialSock         = "serial.sock"
-	SSHSock            = "ssh.sock"
-	GuestAgentSock     = "ga.sock"
-	HostAgentPID       = "ha.pid"
-	HostAgentSock      = "ha.sock"
-	HostAgentStdoutLog = "ha.stdout.log"
-	HostAgentStderrLog = "ha.stderr.log"
+	LimaYAML             = "lima.yaml"
+	CIDataISO            = "cidata.iso"
+	BaseDisk             = "basedisk"
+	DiffDisk             = "diffdisk"
+	QemuPID              = "qemu.pid"
+	QMPSock              = "qmp.sock"
+	SerialLog            = "serial.log"
+	SerialSock           = "serial.sock"
+	SSHSock              = "ssh.sock"
+	GuestAgentSock       = "ga.sock"
+	HostAgentPID         = "ha.pid"
+	HostAgentSock        = "ha.sock"
+	HostAgentStdoutLog   = "ha.stdout.log"
+	HostAgentStderrLog   = "ha.stderr.log"
+	AnsibleInventoryYAML = "ansible-inventory.yaml"
+	SuspendedMarker      = "suspended" // exists while the vCPUs are paused by `limactl suspend`
+	VMState              = "vmstate"   // created by `limactl suspend --save`, removed after restoring the state
 
 	// SocketDir is the default location for forwarded sockets with a relative paths in HostSocket
 	SocketDir = "sock"
@@ -12,9 +12,12 @@ import (
 	"time"
 
 	"github.com/docker/go-units"
+	hostagentapi "github.com/lima-vm/lima/pkg/hostagent/api"
 	hostagentclient "github.com/lima-vm/lima/pkg/hostagent/api/client"
 	"github.com/lima-vm/lima/pkg/limayaml"
+	"github.com/lima-vm/lima/pkg/qemu/imgutil"
 	"github.com/lima-vm/lima/pkg/store/filenames"
+	"github.com/sirupsen/logrus"
 )
 
 type Status = string
@@ -24,21 +27,26 @@ const (
 	StatusBroken  Status = "Broken"
 	StatusStopped Status = "Stopped"
 	StatusRunning Status = "Running"
+	// StatusSuspended is either paused in memory (QemuPID != 0) or saved to disk (QemuPID == 0)
+	StatusSuspended Status = "Suspended"
 )
 
 type Instance struct {
-	Name         string             `json:"name"`
-	Status       Status             `json:"status"`
-	Dir          string             `json:"dir"`
-	Arch         limayaml.Arch      `json:"arch"`
-	CPUs         int                `json:"cpus,omitempty"`
-	Memory       int64              `json:"memory,omitempty"` // bytes
-	Disk         int64              `json:"disk,omitempty"`   // bytes
-	Networks     []limayaml.Network `json:"network,omitempty"`
-	SSHLocalPort int                `json:"sshLocalPort,omitempty"`
-	HostAgentPID int                `json:"hostAgentPID,omitempty"`
-	QemuPID      int                `json:"qemuPID,omitempty"`
-	Errors       []error            `json:"errors,omitempty"`
+	Name         string               `json:"name"`
+	Status       Status               `json:"status"`
+	Dir          string               `json:"dir"`
+	Arch         limayaml.Arch        `json:"arch"`
+	CPUs         int                  `json:"cpus,omitempty"`
+	Memory       int64                `json:"memory,omitempty"` // bytes
+	Disk         int64                `json:"disk,omitempty"`   // bytes
+	Networks     []limayaml.Network   `json:"network,omitempty"`
+	Disks        []Disk               `json:"disks,omitempty"` // additional disks, with the instances that hold them (Size is not filled)
+	SSHLocalPort int                  `json:"sshLocalPort,omitempty"`
+	HostAgentPID int                  `json:"hostAgentPID,omitempty"`
+	QemuPID      int                  `json:"qemuPID,omitempty"`
+	Snapshots    []imgutil.Snapshot   `json:"snapshots,omitempty"` // filled by LoadSnapshots
+	Probes       []hostagentapi.Probe `json:"probes,omitempty"`    // liveness probes
+	Errors       []error              `json:"errors,omitempty"`
 }
 
 func (inst *Instance) LoadYAML() (*limayaml.LimaYAML, error) {
@@ -82,6 +90,22 @@ func Inspect(instName string) (*Instance, error) {
 		inst.Disk = disk
 	}
 	inst.Networks = y.Networks
+	for _, d := range y.AdditionalDisks {
+		disk, err := lookupDisk(d.Name)
+		if err != nil {
+			logrus.WithError(err).Debugf("failed to look up disk %q", d.Name)
+			continue
+		}
+		holder, err := diskHolder(disk.Dir)
+		if err != nil {
+			inst.Errors = append(inst.Errors, err)
+			continue
+		}
+		if holder != "" {
+			disk.Instance, disk.InstanceDir = filepath.Base(holder), holder
+		}
+		inst.Disks = append(inst.Disks, *disk)
+	}
 	inst.SSHLocalPort = y.SSH.LocalPort // maybe 0
 
 	inst.HostAgentPID, err = ReadPIDFile(filepath.Join(instDir, filenames.HostAgentPID))
@@ -105,6 +129,9 @@ func Inspect(instName string) (*Instance, error) {
 				inst.Errors = append(inst.Errors, fmt.Errorf("failed to get Info from %q: %w", haSock, err))
 			} else {
 				inst.SSHLocalPort = info.SSHLocalPort
+				if inst.Probes, err = haClient.Probes(ctx); err != nil {
+					logrus.WithError(err).Debugf("failed to get the liveness probes from %q", haSock)
+				}
 			}
 		}
 	}
@@ -118,8 +145,14 @@ func Inspect(instName string) (*Instance, error) {
 	if inst.Status == StatusUnknown {
 		if inst.HostAgentPID > 0 && inst.QemuPID > 0 {
 			inst.Status = StatusRunning
+			if _, err := os.Stat(filepath.Join(instDir, filenames.SuspendedMarker)); err == nil {
+				inst.Status = StatusSuspended
+			}
 		} else if inst.HostAgentPID == 0 && inst.QemuPID == 0 {
 			inst.Status = StatusStopped
+			if _, err := os.Stat(filepath.Join(instDir, filenames.VMState)); err == nil {
+				inst.Status = StatusSuspended
+			}
 		} else if inst.HostAgentPID > 0 && inst.QemuPID == 0 {
 			inst.Errors = append(inst.Errors, errors.New("host agent is running but qemu is not"))
 			inst.Status = StatusBroken
@@ -132,6 +165,24 @@ func Inspect(instName string) (*Instance, error) {
 	return inst, nil
 }
 
+// LoadSnapshots fills inst.Snapshots with the snapshots of the diffdisk.
+// Inspect does not call LoadSnapshots, as it executes qemu-img.
+func (inst *Instance) LoadSnapshots() error {
+	diffDisk := filepath.Join(inst.Dir, filenames.DiffDisk)
+	if _, err := os.Stat(diffDisk); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	imgInfo, err := imgutil.GetInfoShared(diffDisk)
+	if err != nil {
+		return err
+	}
+	inst.Snapshots = imgInfo.Snapshots
+	return nil
+}
+
 // ReadPIDFile returns 0 if the PID file does not exist or the process has already terminated
 // (in which case the PID file will be removed).
 func ReadPIDFile(path string) (int, error) {
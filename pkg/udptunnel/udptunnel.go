@@ -0,0 +1,96 @@
+// Package udptunnel carries UDP datagrams over a stream connection, such as the
+// guest agent socket that is forwarded to the host via `ssh -L`.
+//
+// Each datagram is encoded as a 2-byte big endian length followed by the payload.
+package udptunnel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Upgrade is the value of the HTTP "Upgrade" header for switching a guest agent
+// connection to a UDP tunnel.
+const Upgrade = "lima-udp"
+
+// MaxDatagramSize is the maximum payload size of a datagram.
+const MaxDatagramSize = 65535
+
+// WriteDatagram writes b to w as a single frame.
+func WriteDatagram(w io.Writer, b []byte) error {
+	if len(b) > MaxDatagramSize {
+		return fmt.Errorf("datagram size %d exceeds %d", len(b), MaxDatagramSize)
+	}
+	frame := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	copy(frame[2:], b)
+	_, err := w.Write(frame)
+	return err
+}
+
+// ReadDatagram reads a single frame from r into buf, and returns the payload size.
+func ReadDatagram(r io.Reader, buf []byte) (int, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, err
+	}
+	n := int(binary.BigEndian.Uint16(hdr[:]))
+	if n > len(buf) {
+		return 0, fmt.Errorf("datagram size %d exceeds the buffer size %d", n, len(buf))
+	}
+	if _, err := io.ReadFull(r, buf[:n]); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Serve relays the datagrams read from stream to the UDP address remote,
+// and the replies from remote back to stream.
+//
+// Serve closes stream and returns when either side is closed.
+func Serve(stream io.ReadWriteCloser, remote string) error {
+	conn, err := net.Dial("udp", remote)
+	if err != nil {
+		_ = stream.Close()
+		return err
+	}
+	defer conn.Close()
+	defer stream.Close()
+
+	errCh := make(chan error, 2)
+	go func() {
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if err := WriteDatagram(stream, buf[:n]); err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, err := ReadDatagram(stream, buf)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+	if err := <-errCh; !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
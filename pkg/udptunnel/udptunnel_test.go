@@ -0,0 +1,31 @@
+package udptunnel
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDatagram(t *testing.T) {
+	var stream bytes.Buffer
+	datagrams := [][]byte{
+		[]byte("foo"),
+		{},
+		bytes.Repeat([]byte{0x42}, MaxDatagramSize),
+	}
+	for _, d := range datagrams {
+		assert.NilError(t, WriteDatagram(&stream, d))
+	}
+	assert.ErrorContains(t, WriteDatagram(&stream, make([]byte, MaxDatagramSize+1)), "exceeds")
+
+	buf := make([]byte, MaxDatagramSize)
+	for _, d := range datagrams {
+		n, err := ReadDatagram(&stream, buf)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, d, buf[:n])
+	}
+	_, err := ReadDatagram(&stream, buf)
+	assert.Equal(t, io.EOF, err)
+}